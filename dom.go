@@ -30,6 +30,7 @@
 package dom
 
 import (
+	"html"
 	"html/template"
 	"strings"
 )
@@ -71,6 +72,13 @@ func InnerHTML(s string) Node {
 	}
 }
 
+// Component is a helper function to name node, e.g. "sidebar", so that its rendering
+// is reported to a Renderer.Tracer. The HTML is unchanged.
+func Component(name string, node Node) Node {
+	node.Component = name
+	return node
+}
+
 // Attribute represents a HTML attribute.
 //
 // This struct is fully exported for the convenience of asserting values during tests
@@ -112,6 +120,9 @@ type Node struct {
 	Children  []Node
 	InnerHTML template.HTML
 	InnerText string
+
+	// Component names the node for a Renderer.Tracer; it does not change the HTML.
+	Component string
 }
 
 // HTML returns the HTML representation of the node.
@@ -143,13 +154,11 @@ func (e Node) buildHTML(sb *strings.Builder) *strings.Builder {
 		attr.buildHTML(sb)
 	}
 
-	switch e.Name {
-	case "area", "base", "br", "col", "command", "embed", "hr", "img", "input", "keygen", "link", "meta", "param", "source", "track", "wbr":
+	if isVoidElement(e.Name) {
 		sb.WriteString("/>")
 		return sb
-	default:
-		sb.WriteString(">")
 	}
+	sb.WriteString(">")
 
 	// buildChildrenHTML (inline to save 32B and 1 alloc)
 	if e.InnerHTML != "" {
@@ -165,6 +174,50 @@ func (e Node) buildHTML(sb *strings.Builder) *strings.Builder {
 	return sb
 }
 
+// Attr returns the value of the first attribute named name, and whether there is one.
+func (e Node) Attr(name string) (string, bool) {
+	for _, attr := range e.Attributes {
+		if attr.Name != name {
+			continue
+		}
+		if attr.ValueHTML != "" {
+			return html.UnescapeString(string(attr.ValueHTML)), true
+		}
+		return attr.ValueText, true
+	}
+	return "", false
+}
+
+// SetAttr returns a copy of the node with the value of attribute name set to value,
+// replacing the first attribute of that name or else adding one. The Attributes of
+// the receiver are not modified.
+func (e Node) SetAttr(name, value string) Node {
+	attrs := make([]Attribute, 0, len(e.Attributes)+1)
+	found := false
+	for _, attr := range e.Attributes {
+		if attr.Name == name && !found {
+			attr = Attribute{Name: name, ValueText: value}
+			found = true
+		}
+		attrs = append(attrs, attr)
+	}
+	if !found {
+		attrs = append(attrs, Attribute{Name: name, ValueText: value})
+	}
+	e.Attributes = attrs
+	return e
+}
+
+// isVoidElement reports whether name is an element that cannot have children,
+// e.g. `<br/>` and `<img/>`, and is therefore self-closed.
+func isVoidElement(name string) bool {
+	switch name {
+	case "area", "base", "br", "col", "command", "embed", "hr", "img", "input", "keygen", "link", "meta", "param", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
+
 // Helper functions for every html element, using Element() and InnerText() helpers.
 
 // A returns a Node with name "a".
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"runtime"
 	"testing"
 
 	"github.com/choonkeat/dom-go"
@@ -64,6 +65,26 @@ func TestEmptyElement(t *testing.T) {
 
 }
 
+func TestSetAttr(t *testing.T) {
+	t.Parallel()
+
+	given := dom.A(dom.Attrs("href", "/a", "class", "x"))
+	got := given.SetAttr("href", "/b?x=1&y=2").SetAttr("rel", "next")
+	want := template.HTML(`<a href="/b?x=1&amp;y=2" class="x" rel="next"></a>`)
+	if got.HTML() != want {
+		t.Fatalf("want %#v but got %#v", want, got.HTML())
+	}
+	if value, ok := got.Attr("href"); !ok || value != "/b?x=1&y=2" {
+		t.Fatalf("want %#v but got %#v %v", "/b?x=1&y=2", value, ok)
+	}
+	if _, ok := got.Attr("target"); ok {
+		t.Fatalf("want no target attribute")
+	}
+	if given.HTML() != `<a href="/a" class="x"></a>` {
+		t.Fatalf("SetAttr modified the given node: %#v", given.HTML())
+	}
+}
+
 func Example() {
 	fmt.Println(
 		// use dom.Element or dom.Div
@@ -154,6 +175,27 @@ func BenchmarkNodeHTML(b *testing.B) {
 	b.ReportMetric(float64(b.N), "NodeHTML")
 }
 
+func BenchmarkNodeHTMLTable(b *testing.B) {
+	table := tableRows(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.HTML()
+	}
+	b.ReportAllocs()
+	b.ReportMetric(float64(b.N), "NodeHTMLTable")
+}
+
+func BenchmarkRendererHTMLTable(b *testing.B) {
+	table := tableRows(1000)
+	renderer := dom.Renderer{Workers: runtime.GOMAXPROCS(0)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		renderer.HTML(table)
+	}
+	b.ReportAllocs()
+	b.ReportMetric(float64(b.N), "RendererHTMLTable")
+}
+
 func BenchmarkHtmlTemplate(b *testing.B) {
 	tmpl, err := template.New("index.html").Parse(`<a href="{{ .Href }}" target="{{ .Target }}">{{ .Text1 }}<blockquote>{{ .Text2 }}</blockquote></a>`)
 	if err != nil {
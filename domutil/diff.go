@@ -0,0 +1,397 @@
+package domutil
+
+import (
+	"fmt"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// PatchOp is the kind of change a Patch makes.
+type PatchOp int
+
+const (
+	// InsertChild inserts Node as the child at Index of the node at Path.
+	InsertChild PatchOp = iota
+
+	// RemoveChild removes the child at Index of the node at Path.
+	RemoveChild
+
+	// MoveChild removes the child at From of the node at Path, and inserts it back
+	// at Index, counted without it.
+	MoveChild
+
+	// SetAttr sets the attribute of the node at Path named Attr.Name to Attr,
+	// replacing the first attribute of that name or else adding one at the end.
+	SetAttr
+
+	// RemoveAttr removes the attributes of the node at Path named Attr.Name.
+	RemoveAttr
+
+	// ReplaceText sets the InnerText and InnerHTML of the node at Path to those of
+	// Node, and removes its children.
+	ReplaceText
+
+	// ReplaceNode replaces the node at Path with Node.
+	ReplaceNode
+)
+
+// String returns the name of the op, e.g. "InsertChild".
+func (op PatchOp) String() string {
+	switch op {
+	case InsertChild:
+		return "InsertChild"
+	case RemoveChild:
+		return "RemoveChild"
+	case MoveChild:
+		return "MoveChild"
+	case SetAttr:
+		return "SetAttr"
+	case RemoveAttr:
+		return "RemoveAttr"
+	case ReplaceText:
+		return "ReplaceText"
+	case ReplaceNode:
+		return "ReplaceNode"
+	}
+	return fmt.Sprintf("PatchOp(%d)", int(op))
+}
+
+// Patch is a change to a tree, made by Diff and Apply.
+//
+// Path addresses a node like Cursor.Path does, in the tree as it is after the
+// patches before it. For InsertChild, RemoveChild and MoveChild it is the parent
+// of the child at Index.
+type Patch struct {
+	Op    PatchOp
+	Path  []int
+	Index int
+	From  int
+	Node  dom.Node
+	Attr  dom.Attribute
+}
+
+// KeyAttr is the attribute that tells Diff which children are the same node,
+// e.g. the items of a list that was reordered.
+const KeyAttr = "key"
+
+// Diff returns the patches that change old into new, so that Apply(old, patches)
+// renders the same html as new, with the same Components.
+//
+// Children are matched by their KeyAttr when they have one, and otherwise by their
+// order among the children without one. Matched children are patched in place,
+// moving as few as possible; the others are removed or inserted.
+func Diff(old, new dom.Node) []Patch {
+	var patches []Patch
+	diffNode(&patches, []int{}, old, new)
+	return patches
+}
+
+func diffNode(patches *[]Patch, path []int, old, new dom.Node) {
+	if old.Name != new.Name || old.Component != new.Component || !diffableAttrs(old.Attributes) || !diffableAttrs(new.Attributes) {
+		*patches = append(*patches, Patch{Op: ReplaceNode, Path: path, Node: new})
+		return
+	}
+	diffAttrs(patches, path, old.Attributes, new.Attributes)
+
+	oldChildren := old.Children
+	if old.InnerText != new.InnerText || old.InnerHTML != new.InnerHTML {
+		*patches = append(*patches, Patch{Op: ReplaceText, Path: path, Node: dom.Node{InnerText: new.InnerText, InnerHTML: new.InnerHTML}})
+		oldChildren = nil
+	}
+	diffChildren(patches, path, oldChildren, new.Children)
+}
+
+// diffableAttrs reports whether attrs can be patched by name, i.e. no name is used
+// twice.
+func diffableAttrs(attrs []dom.Attribute) bool {
+	for i := range attrs {
+		for j := i + 1; j < len(attrs); j++ {
+			if attrs[i].Name == attrs[j].Name {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func diffAttrs(patches *[]Patch, path []int, old, new []dom.Attribute) {
+	newIndex := map[string]int{}
+	for i, attr := range new {
+		newIndex[attr.Name] = i
+	}
+
+	// the attributes that stay in place, in order, are those kept in their new
+	// order; the rest are removed, and set again at the end
+	var kept []dom.Attribute
+	next := 0
+	for _, attr := range old {
+		i, ok := newIndex[attr.Name]
+		if !ok || i < next {
+			*patches = append(*patches, Patch{Op: RemoveAttr, Path: path, Attr: dom.Attribute{Name: attr.Name}})
+			continue
+		}
+		kept = append(kept, attr)
+		next = i + 1
+	}
+
+	// kept attributes after a new attribute has to be added before them are moved
+	// to the end as well
+	k := 0
+	for _, attr := range new {
+		if k < len(kept) && kept[k].Name == attr.Name {
+			if kept[k] != attr {
+				*patches = append(*patches, Patch{Op: SetAttr, Path: path, Attr: attr})
+			}
+			k++
+			continue
+		}
+		for ; k < len(kept); k++ {
+			*patches = append(*patches, Patch{Op: RemoveAttr, Path: path, Attr: dom.Attribute{Name: kept[k].Name}})
+		}
+		*patches = append(*patches, Patch{Op: SetAttr, Path: path, Attr: attr})
+	}
+}
+
+func diffChildren(patches *[]Patch, path []int, old, new []dom.Node) {
+	// match new children to old ones, by key or else by order
+	oldByKey := map[string]int{}
+	var oldUnkeyed []int
+	for i, child := range old {
+		if key, ok := child.Attr(KeyAttr); ok {
+			if _, dup := oldByKey[key]; !dup {
+				oldByKey[key] = i
+				continue
+			}
+		}
+		oldUnkeyed = append(oldUnkeyed, i)
+	}
+	match := make([]int, len(new)) // index of the matching old child, or -1
+	matched := make([]bool, len(old))
+	for i, child := range new {
+		match[i] = -1
+		if key, ok := child.Attr(KeyAttr); ok {
+			if j, found := oldByKey[key]; found && !matched[j] {
+				match[i] = j
+				matched[j] = true
+			}
+			continue
+		}
+		if len(oldUnkeyed) > 0 {
+			match[i] = oldUnkeyed[0]
+			matched[oldUnkeyed[0]] = true
+			oldUnkeyed = oldUnkeyed[1:]
+		}
+	}
+
+	// remove the old children that are not matched, from the last so that the
+	// indices of the others stay the same
+	for j := len(old) - 1; j >= 0; j-- {
+		if !matched[j] {
+			*patches = append(*patches, Patch{Op: RemoveChild, Path: path, Index: j})
+		}
+	}
+
+	// current is the old children left, by their index in old
+	var current []int
+	for j := range old {
+		if matched[j] {
+			current = append(current, j)
+		}
+	}
+
+	// matched children in the longest run already in order stay where they are;
+	// going backwards, the others are moved or inserted before the child after them
+	var order []int // index in new of matched children
+	for i, j := range match {
+		if j >= 0 {
+			order = append(order, i)
+		}
+	}
+	stays := map[int]bool{}
+	for _, k := range longestIncreasing(order, match) {
+		stays[order[k]] = true
+	}
+	indexOf := func(j int) int {
+		for k, c := range current {
+			if c == j {
+				return k
+			}
+		}
+		return -1
+	}
+	for i := len(new) - 1; i >= 0; i-- {
+		if stays[i] {
+			continue
+		}
+		to := len(current)
+		if i+1 < len(new) {
+			to = indexOf(match[i+1])
+		}
+		j := match[i]
+		if j < 0 {
+			*patches = append(*patches, Patch{Op: InsertChild, Path: path, Index: to, Node: new[i]})
+			match[i] = -2 - i // not an index in old, but still unique to the child
+			current = append(current[:to], append([]int{match[i]}, current[to:]...)...)
+			continue
+		}
+		from := indexOf(j)
+		current = append(current[:from], current[from+1:]...)
+		if from < to {
+			to--
+		}
+		if from != to {
+			*patches = append(*patches, Patch{Op: MoveChild, Path: path, From: from, Index: to})
+		}
+		current = append(current[:to], append([]int{j}, current[to:]...)...)
+	}
+
+	// patch the matched children, now at their new index
+	for i, j := range match {
+		if j >= 0 {
+			diffNode(patches, append(path[:len(path):len(path)], i), old[j], new[i])
+		}
+	}
+}
+
+// longestIncreasing returns the positions in order of a longest run of children
+// whose matches are increasing, i.e. children that are already in order.
+func longestIncreasing(order []int, match []int) []int {
+	var tails []int // position in order of the smallest tail of a run of each length
+	prev := make([]int, len(order))
+	for k, i := range order {
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if match[order[tails[mid]]] < match[i] {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[k] = -1
+		if lo > 0 {
+			prev[k] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, k)
+		} else {
+			tails[lo] = k
+		}
+	}
+	run := make([]int, len(tails))
+	for k, at := len(tails)-1, -1; k >= 0; k-- {
+		if at < 0 {
+			at = tails[k]
+		}
+		run[k] = at
+		at = prev[at]
+	}
+	return run
+}
+
+// Apply returns tree with the patches made by Diff applied in order. The given
+// tree is not modified. It returns an error if a patch does not fit the tree.
+func Apply(tree dom.Node, patches []Patch) (dom.Node, error) {
+	for i, patch := range patches {
+		var err error
+		tree, err = applyPatch(tree, patch.Path, patch)
+		if err != nil {
+			return dom.Node{}, fmt.Errorf("patch %d: %s %v: %w", i, patch.Op, patch.Path, err)
+		}
+	}
+	return tree, nil
+}
+
+// applyPatch returns n with patch applied to its descendant at path.
+func applyPatch(n dom.Node, path []int, patch Patch) (dom.Node, error) {
+	if len(path) > 0 {
+		i := path[0]
+		if i < 0 || i >= len(n.Children) {
+			return n, fmt.Errorf("no child %d", i)
+		}
+		child, err := applyPatch(n.Children[i], path[1:], patch)
+		if err != nil {
+			return n, err
+		}
+		children := append([]dom.Node(nil), n.Children...)
+		children[i] = child
+		n.Children = children
+		return n, nil
+	}
+
+	switch patch.Op {
+	case InsertChild:
+		if patch.Index < 0 || patch.Index > len(n.Children) {
+			return n, fmt.Errorf("no index %d", patch.Index)
+		}
+		children := make([]dom.Node, 0, len(n.Children)+1)
+		children = append(children, n.Children[:patch.Index]...)
+		children = append(children, patch.Node)
+		n.Children = append(children, n.Children[patch.Index:]...)
+
+	case RemoveChild:
+		if patch.Index < 0 || patch.Index >= len(n.Children) {
+			return n, fmt.Errorf("no child %d", patch.Index)
+		}
+		children := make([]dom.Node, 0, len(n.Children)-1)
+		children = append(children, n.Children[:patch.Index]...)
+		n.Children = nilIfEmpty(append(children, n.Children[patch.Index+1:]...))
+
+	case MoveChild:
+		if patch.From < 0 || patch.From >= len(n.Children) {
+			return n, fmt.Errorf("no child %d", patch.From)
+		}
+		if patch.Index < 0 || patch.Index >= len(n.Children) {
+			return n, fmt.Errorf("no index %d", patch.Index)
+		}
+		moved := n.Children[patch.From]
+		children := make([]dom.Node, 0, len(n.Children))
+		children = append(children, n.Children[:patch.From]...)
+		children = append(children, n.Children[patch.From+1:]...)
+		children = append(children[:patch.Index], append([]dom.Node{moved}, children[patch.Index:]...)...)
+		n.Children = children
+
+	case SetAttr:
+		attrs := make([]dom.Attribute, 0, len(n.Attributes)+1)
+		found := false
+		for _, attr := range n.Attributes {
+			if attr.Name == patch.Attr.Name && !found {
+				attr = patch.Attr
+				found = true
+			}
+			attrs = append(attrs, attr)
+		}
+		if !found {
+			attrs = append(attrs, patch.Attr)
+		}
+		n.Attributes = attrs
+
+	case RemoveAttr:
+		var attrs []dom.Attribute
+		for _, attr := range n.Attributes {
+			if attr.Name != patch.Attr.Name {
+				attrs = append(attrs, attr)
+			}
+		}
+		n.Attributes = attrs
+
+	case ReplaceText:
+		n.InnerText = patch.Node.InnerText
+		n.InnerHTML = patch.Node.InnerHTML
+		n.Children = nil
+
+	case ReplaceNode:
+		return patch.Node, nil
+
+	default:
+		return n, fmt.Errorf("unknown op")
+	}
+	return n, nil
+}
+
+func nilIfEmpty(nodes []dom.Node) []dom.Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes
+}
@@ -0,0 +1,170 @@
+package domutil_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+func TestDiff(t *testing.T) {
+	item := func(key string) dom.Node {
+		return dom.Li(dom.Attrs("key", key), dom.InnerText(key))
+	}
+	tests := []struct {
+		name string
+		old  dom.Node
+		new  dom.Node
+		want []domutil.Patch
+	}{
+		{
+			name: "same",
+			old:  dom.Div(dom.Attrs("class", "a"), dom.P(dom.Attrs(), dom.InnerText("hi"))),
+			new:  dom.Div(dom.Attrs("class", "a"), dom.P(dom.Attrs(), dom.InnerText("hi"))),
+			want: nil,
+		},
+		{
+			name: "attributes",
+			old:  dom.Div(dom.Attrs("id", "x", "class", "a", "title", "t")),
+			new:  dom.Div(dom.Attrs("id", "x", "class", "b", "hidden", "")),
+			want: []domutil.Patch{
+				{Op: domutil.RemoveAttr, Path: []int{}, Attr: dom.Attribute{Name: "title"}},
+				{Op: domutil.SetAttr, Path: []int{}, Attr: dom.Attribute{Name: "class", ValueText: "b"}},
+				{Op: domutil.SetAttr, Path: []int{}, Attr: dom.Attribute{Name: "hidden"}},
+			},
+		},
+		{
+			name: "reordered attributes",
+			old:  dom.Div(dom.Attrs("a", "1", "b", "2", "c", "3")),
+			new:  dom.Div(dom.Attrs("c", "3", "a", "1", "b", "2")),
+		},
+		{
+			name: "text",
+			old:  dom.P(dom.Attrs(), dom.InnerText("hello"), dom.Em(dom.Attrs(), dom.InnerText("world"))),
+			new:  dom.P(dom.Attrs(), dom.InnerText("goodbye"), dom.Em(dom.Attrs(), dom.InnerText("world"))),
+			want: []domutil.Patch{
+				{Op: domutil.ReplaceText, Path: []int{0}, Node: dom.InnerText("goodbye")},
+			},
+		},
+		{
+			name: "text to children and back",
+			old:  dom.Div(dom.Attrs(), dom.P(dom.Attrs(), dom.InnerText("a")), dom.P(dom.Attrs(), dom.Em(dom.Attrs(), dom.InnerText("b")))),
+			new:  dom.Div(dom.Attrs(), dom.P(dom.Attrs(), dom.Em(dom.Attrs(), dom.InnerText("a"))), dom.P(dom.Attrs(), dom.InnerHTML("<b>b</b>"))),
+		},
+		{
+			name: "keyed move",
+			old:  dom.Ul(dom.Attrs(), item("a"), item("b"), item("c"), item("d")),
+			new:  dom.Ul(dom.Attrs(), item("b"), item("c"), item("d"), item("a")),
+			want: []domutil.Patch{
+				{Op: domutil.MoveChild, Path: []int{}, From: 0, Index: 3},
+			},
+		},
+		{
+			name: "keyed insert and remove",
+			old:  dom.Ul(dom.Attrs(), item("a"), item("b"), item("c")),
+			new:  dom.Ul(dom.Attrs(), item("c"), item("x"), item("a")),
+			want: []domutil.Patch{
+				{Op: domutil.RemoveChild, Path: []int{}, Index: 1},
+				{Op: domutil.InsertChild, Path: []int{}, Index: 0, Node: item("x")},
+				{Op: domutil.MoveChild, Path: []int{}, From: 2, Index: 0},
+			},
+		},
+		{
+			name: "keyed shuffle with changes",
+			old:  dom.Ul(dom.Attrs(), item("a"), item("b"), item("c"), item("d"), item("e"), item("f")),
+			new: dom.Ul(dom.Attrs(),
+				item("f"), dom.Li(dom.Attrs("key", "d", "class", "done"), dom.InnerText("D")), item("b"),
+				item("g"), item("a"), item("e"),
+			),
+		},
+		{
+			name: "unkeyed children by order",
+			old:  dom.Div(dom.Attrs(), dom.P(dom.Attrs(), dom.InnerText("a")), dom.Hr(dom.Attrs())),
+			new:  dom.Div(dom.Attrs(), dom.P(dom.Attrs(), dom.InnerText("b"))),
+			want: []domutil.Patch{
+				{Op: domutil.RemoveChild, Path: []int{}, Index: 1},
+				{Op: domutil.ReplaceText, Path: []int{0, 0}, Node: dom.InnerText("b")},
+			},
+		},
+		{
+			name: "different element",
+			old:  dom.Div(dom.Attrs(), dom.P(dom.Attrs(), dom.InnerText("a"))),
+			new:  dom.Div(dom.Attrs(), dom.H1(dom.Attrs(), dom.InnerText("a"))),
+			want: []domutil.Patch{
+				{Op: domutil.ReplaceNode, Path: []int{0}, Node: dom.H1(dom.Attrs(), dom.InnerText("a"))},
+			},
+		},
+		{
+			name: "duplicate keys and attributes",
+			old:  dom.Ul(dom.Attrs(), item("a"), item("a"), dom.Li(dom.Attrs("class", "x", "class", "y"))),
+			new:  dom.Ul(dom.Attrs(), dom.Li(dom.Attrs("class", "z")), item("a"), item("b"), item("a")),
+		},
+		{
+			name: "component",
+			old:  dom.Component("old", dom.Div(dom.Attrs())),
+			new:  dom.Component("new", dom.Div(dom.Attrs())),
+			want: []domutil.Patch{
+				{Op: domutil.ReplaceNode, Path: []int{}, Node: dom.Component("new", dom.Div(dom.Attrs()))},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldHTML := tt.old.HTML()
+			patches := domutil.Diff(tt.old, tt.new)
+			if tt.want != nil || tt.name == "same" {
+				if !reflect.DeepEqual(patches, tt.want) {
+					t.Errorf("\ngot      %+v\nbut want %+v", patches, tt.want)
+				}
+			}
+
+			got, err := domutil.Apply(tt.old, patches)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.HTML() != tt.new.HTML() || got.Component != tt.new.Component {
+				t.Errorf("\ngot      %q\nbut want %q", got.HTML(), tt.new.HTML())
+			}
+			if tt.old.HTML() != oldHTML {
+				t.Errorf("old was modified: %q", tt.old.HTML())
+			}
+		})
+	}
+}
+
+func TestApplyError(t *testing.T) {
+	tree := dom.Ul(dom.Attrs(), dom.Li(dom.Attrs()))
+	tests := []struct {
+		name    string
+		given   []domutil.Patch
+		wantErr string
+	}{
+		{
+			name:    "no such path",
+			given:   []domutil.Patch{{Op: domutil.SetAttr, Path: []int{1}, Attr: dom.Attribute{Name: "id"}}},
+			wantErr: "patch 0: SetAttr [1]: no child 1",
+		},
+		{
+			name: "no such index",
+			given: []domutil.Patch{
+				{Op: domutil.RemoveChild, Path: []int{}, Index: 0},
+				{Op: domutil.RemoveChild, Path: []int{}, Index: 0},
+			},
+			wantErr: "patch 1: RemoveChild []: no child 0",
+		},
+		{
+			name:    "insert past the end",
+			given:   []domutil.Patch{{Op: domutil.InsertChild, Path: []int{}, Index: 2}},
+			wantErr: "patch 0: InsertChild []: no index 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := domutil.Apply(tree, tt.given)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("\ngot      %v\nbut want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package domutil
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// DefaultSchemes are the URL schemes that FormatText turns into links by default.
+var DefaultSchemes = []string{"http", "https", "mailto"}
+
+// FormatTextOptions configures FormatText. The zero value is ready to use.
+type FormatTextOptions struct {
+	// Rel and Target, if not empty, are set on every link, e.g. "nofollow noopener"
+	// and "_blank".
+	Rel    string
+	Target string
+
+	// Schemes are the URL schemes that are turned into links; anything else, e.g.
+	// `javascript:...`, is left as text. Defaults to DefaultSchemes. Email addresses
+	// need "mailto", and `www.` addresses need "https" or "http".
+	Schemes []string
+}
+
+// FormatText turns plain text, e.g. a user comment, into paragraphs with links. Text
+// separated by blank lines are in `<p>`, single newlines are `<br/>`, and URLs and
+// email addresses are links.
+//
+// All of the text is still InnerText, escaped as usual.
+func FormatText(text string, opts FormatTextOptions) dom.Node {
+	schemes := opts.Schemes
+	if schemes == nil {
+		schemes = DefaultSchemes
+	}
+
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	var paragraphs []dom.Node
+	for _, paragraph := range blankLines.Split(text, -1) {
+		paragraph = strings.Trim(paragraph, "\n")
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		var children []dom.Node
+		for i, line := range strings.Split(paragraph, "\n") {
+			if i > 0 {
+				children = append(children, dom.Br(dom.Attrs()))
+			}
+			children = append(children, linkify(line, schemes, opts))
+		}
+		paragraphs = append(paragraphs, dom.P(dom.Attrs(), children...))
+	}
+	return Join(paragraphs...)
+}
+
+var (
+	blankLines = regexp.MustCompile(`\n[ \t]*\n\s*`)
+
+	linkLike = regexp.MustCompile(`(?i)(?:\b[a-z][a-z0-9+.\-]*:(?://)?|\bwww\.)[^\s<>"]+|\b[a-z0-9._%+\-]+@[a-z0-9\-]+(?:\.[a-z0-9\-]+)+`)
+)
+
+// linkify returns line with its URLs and email addresses as links.
+func linkify(line string, schemes []string, opts FormatTextOptions) dom.Node {
+	return ReplaceAllRegexp(dom.InnerText(line), linkLike, func(match []string) dom.Node {
+		text, trailing := trimTrailingPunctuation(match[0])
+		href := linkHref(text, schemes)
+		if href == "" {
+			return dom.InnerText(match[0])
+		}
+
+		attrs := dom.Attrs("href", href)
+		if opts.Rel != "" {
+			attrs = append(attrs, dom.Attrs("rel", opts.Rel)...)
+		}
+		if opts.Target != "" {
+			attrs = append(attrs, dom.Attrs("target", opts.Target)...)
+		}
+		link := dom.A(attrs, dom.InnerText(text))
+		if trailing == "" {
+			return link
+		}
+		return Join(link, dom.InnerText(trailing))
+	})
+}
+
+// linkHref returns the URL to link text to, or "" if its scheme is not allowed.
+func linkHref(text string, schemes []string) string {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.HasPrefix(lower, "www."):
+		for _, scheme := range []string{"https", "http"} {
+			if containsFold(schemes, scheme) {
+				return scheme + "://" + text
+			}
+		}
+		return ""
+	case !strings.Contains(lower, ":"):
+		if containsFold(schemes, "mailto") {
+			return "mailto:" + text
+		}
+		return ""
+	}
+
+	scheme, rest, _ := strings.Cut(lower, ":")
+	if !containsFold(schemes, scheme) || strings.Trim(rest, "/") == "" {
+		return ""
+	}
+	return text
+}
+
+// trimTrailingPunctuation splits off the punctuation that ends a sentence rather
+// than a URL, e.g. the "." of "see https://example.com." and the ")" of
+// "(https://example.com)", but not of "https://en.wikipedia.org/wiki/Go_(game)".
+func trimTrailingPunctuation(s string) (string, string) {
+	end := len(s)
+	for end > 0 {
+		c := s[end-1]
+		if strings.IndexByte(".,;:!?'\"", c) >= 0 {
+			end--
+			continue
+		}
+		if c == ')' && strings.Count(s[:end], "(") < strings.Count(s[:end], ")") {
+			end--
+			continue
+		}
+		if c == ']' && strings.Count(s[:end], "[") < strings.Count(s[:end], "]") {
+			end--
+			continue
+		}
+		break
+	}
+	return s[:end], s[end:]
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package domutil_test
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+func TestFormatText(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		opts  domutil.FormatTextOptions
+		want  template.HTML
+	}{
+		{
+			name:  "paragraphs and line breaks",
+			given: "Dear <team>,\r\nthanks!\n\n  \n\nBye & see you\n",
+			want:  `<p>Dear &lt;team&gt;,<br/>thanks!</p><p>Bye &amp; see you</p>`,
+		},
+		{
+			name:  "links",
+			given: "See https://example.com/a?b=1&c=2, (http://example.com/x) or www.example.com.\nhttps://en.wikipedia.org/wiki/Go_(game)!",
+			want: `<p>See <a href="https://example.com/a?b=1&amp;c=2">https://example.com/a?b=1&amp;c=2</a>, ` +
+				`(<a href="http://example.com/x">http://example.com/x</a>) or <a href="https://www.example.com">www.example.com</a>.<br/>` +
+				`<a href="https://en.wikipedia.org/wiki/Go_(game)">https://en.wikipedia.org/wiki/Go_(game)</a>!</p>`,
+		},
+		{
+			name:  "email addresses",
+			given: "mail alice@example.com or mailto:bob@example.co.uk.",
+			want:  `<p>mail <a href="mailto:alice@example.com">alice@example.com</a> or <a href="mailto:bob@example.co.uk">mailto:bob@example.co.uk</a>.</p>`,
+		},
+		{
+			name:  "schemes not allowed",
+			given: "javascript:alert(1) ftp://example.com note:this http://",
+			want:  `<p>javascript:alert(1) ftp://example.com note:this http://</p>`,
+		},
+		{
+			name:  "options",
+			given: "ftp://example.com and https://example.com and alice@example.com",
+			opts:  domutil.FormatTextOptions{Rel: "nofollow noopener", Target: "_blank", Schemes: []string{"ftp", "https"}},
+			want:  `<p><a href="ftp://example.com" rel="nofollow noopener" target="_blank">ftp://example.com</a> and <a href="https://example.com" rel="nofollow noopener" target="_blank">https://example.com</a> and alice@example.com</p>`,
+		},
+		{
+			name:  "blank",
+			given: " \n\n ",
+			want:  ``,
+		},
+	}
+	for _, tt := range tests {
+		if got := domutil.FormatText(tt.given, tt.opts).HTML(); got != tt.want {
+			t.Errorf("%s:\ngot      %q\nbut want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package domutil
+
+import (
+	"net/http"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// Fragment returns the first element of tree, in the order they are rendered, with
+// the id, and whether there is one.
+func Fragment(tree dom.Node, id string) (dom.Node, bool) {
+	var found dom.Node
+	ok := false
+	Walk(tree, Visitor{Enter: func(c Cursor) WalkAction {
+		if c.Node.Name == "" {
+			return Continue
+		}
+		if value, has := attrValue(c.Node, "id"); has && value == id {
+			found, ok = c.Node, true
+			return Stop
+		}
+		return Continue
+	}})
+	return found, ok
+}
+
+// FragmentParam is the query parameter with a CSS selector of the part of the page
+// to serve, e.g. `?fragment=%23cart` for the element with id "cart".
+const FragmentParam = "fragment"
+
+// FragmentFor returns the part of page that r asks for, so that one dom.Handler
+// serves both a page and its partial updates, e.g.
+//
+//	http.Handle("/", dom.Handler(func(r *http.Request) (dom.Node, error) {
+//		return domutil.FragmentFor(r, page(r))
+//	}))
+//
+// The part is
+//
+//   - the element matching the selector of the FragmentParam query parameter,
+//   - the element with the id of the `Turbo-Frame` header, for a Turbo frame,
+//   - the element with the id of the `HX-Target` header, for an htmx request,
+//
+// or else the whole page. The error is a *dom.HTTPError, 404 if there is no such
+// element, and 400 for a selector that cannot be compiled. Inside a dom.Handler, the
+// response varies by the headers above.
+//
+// As the target element itself is returned, htmx requests must replace their
+// target with `hx-swap="outerHTML"`. The default `innerHTML` would put the target
+// inside itself; for other swaps, use a FragmentParam selector in the request URL.
+func FragmentFor(r *http.Request, page dom.Node) (dom.Node, error) {
+	dom.AddHeader(r, "Vary", "HX-Request, HX-Target, Turbo-Frame")
+
+	var part dom.Node
+	var found bool
+	switch selector, frame, target := r.URL.Query().Get(FragmentParam), r.Header.Get("Turbo-Frame"), r.Header.Get("HX-Target"); {
+	case selector != "":
+		var err error
+		part, found, err = QuerySelector(page, selector)
+		if err != nil {
+			return dom.Node{}, &dom.HTTPError{Status: http.StatusBadRequest, Message: err.Error(), Err: err}
+		}
+	case frame != "":
+		part, found = Fragment(page, frame)
+	case target != "" && r.Header.Get("HX-Request") == "true" && r.Header.Get("HX-Boosted") != "true":
+		part, found = Fragment(page, target)
+	default:
+		return page, nil
+	}
+	if !found {
+		return dom.Node{}, &dom.HTTPError{Status: http.StatusNotFound, Message: "fragment not found"}
+	}
+	return part, nil
+}
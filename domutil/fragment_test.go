@@ -0,0 +1,126 @@
+package domutil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+func fragmentPage() dom.Node {
+	return dom.Html(dom.Attrs(),
+		dom.Body(dom.Attrs(),
+			dom.Element("turbo-frame", dom.Attrs("id", "messages"),
+				dom.P(dom.Attrs(), dom.InnerText("hello")),
+			),
+			dom.Div(dom.Attrs("id", "cart", "class", "cart"),
+				dom.Span(dom.Attrs("class", "count"), dom.InnerText("3")),
+				dom.InnerText(" items"),
+			),
+			dom.Div(dom.Attrs("id", "cart"), dom.InnerText("second")),
+		),
+	)
+}
+
+func TestFragment(t *testing.T) {
+	tests := []struct {
+		name   string
+		given  string
+		want   string
+		wantOK bool
+	}{
+		{name: "first with the id", given: "cart", want: `<div id="cart" class="cart"><span class="count">3</span> items</div>`, wantOK: true},
+		{name: "custom element", given: "messages", want: `<turbo-frame id="messages"><p>hello</p></turbo-frame>`, wantOK: true},
+		{name: "missing", given: "nope", want: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := domutil.Fragment(fragmentPage(), tt.given)
+			if string(got.HTML()) != tt.want || ok != tt.wantOK {
+				t.Errorf("\ngot      %q, %v\nbut want %q, %v", got.HTML(), ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFragmentFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		header     map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "whole page",
+			url:        "/",
+			wantStatus: http.StatusOK,
+			wantBody:   "<!DOCTYPE html>" + string(fragmentPage().HTML()),
+		},
+		{
+			name:       "selector",
+			url:        "/?fragment=.cart+.count",
+			wantStatus: http.StatusOK,
+			wantBody:   `<span class="count">3</span>`,
+		},
+		{
+			name:       "turbo frame",
+			url:        "/",
+			header:     map[string]string{"Turbo-Frame": "messages"},
+			wantStatus: http.StatusOK,
+			wantBody:   `<turbo-frame id="messages"><p>hello</p></turbo-frame>`,
+		},
+		{
+			name:       "htmx target",
+			url:        "/",
+			header:     map[string]string{"HX-Request": "true", "HX-Target": "cart"},
+			wantStatus: http.StatusOK,
+			wantBody:   `<div id="cart" class="cart"><span class="count">3</span> items</div>`,
+		},
+		{
+			name:       "htmx boosted",
+			url:        "/",
+			header:     map[string]string{"HX-Request": "true", "HX-Boosted": "true", "HX-Target": "cart"},
+			wantStatus: http.StatusOK,
+			wantBody:   "<!DOCTYPE html>" + string(fragmentPage().HTML()),
+		},
+		{
+			name:       "not found",
+			url:        "/",
+			header:     map[string]string{"Turbo-Frame": "nope"},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "<!DOCTYPE html>" + string(dom.ErrorPage(http.StatusNotFound, "fragment not found").HTML()),
+		},
+		{
+			name:       "invalid selector",
+			url:        "/?fragment=%5B",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	handler := dom.Handler(func(r *http.Request) (dom.Node, error) {
+		dom.SetHeader(r, "Vary", "Cookie")
+		return domutil.FragmentFor(r, fragmentPage())
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("got status %d but want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("\ngot      %q\nbut want %q", w.Body.String(), tt.wantBody)
+			}
+			if got, want := w.Header().Values("Vary"), []string{"Cookie", "HX-Request, HX-Target, Turbo-Frame", "Accept-Encoding"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("\ngot Vary      %q\nbut want Vary %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package domutil
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// Highlight wraps every occurrence of the search terms in the text of tree with
+// wrap(occurrence), or in a `<mark>` if wrap is nil. Like ReplaceAll, attribute
+// values and markup are never matched, and the given tree is not modified.
+//
+// Terms are trimmed, and match case-insensitively and ignoring diacritics, e.g. "cafe" matches
+// "Café", and the occurrence given to wrap is the text as it was. Where terms
+// overlap, the longest wins. Use the WholeWords option to not match inside longer
+// words, and AcrossInline to match text split by formatting.
+//
+// Example:
+//
+//	results = domutil.Highlight(results, strings.Fields(query), nil, domutil.WholeWords())
+func Highlight(tree dom.Node, terms []string, wrap func(occurrence string) dom.Node, opts ...ReplaceOption) dom.Node {
+	if wrap == nil {
+		wrap = func(occurrence string) dom.Node {
+			return dom.Mark(dom.Attrs(), dom.InnerText(occurrence))
+		}
+	}
+
+	var folded []string
+	for _, term := range terms {
+		if term, _ := foldText(strings.TrimSpace(term)); term != "" {
+			folded = append(folded, term)
+		}
+	}
+	if len(folded) == 0 {
+		return tree
+	}
+	sort.SliceStable(folded, func(i, j int) bool { return len(folded[i]) > len(folded[j]) })
+
+	return replacer{
+		find: func(text string) [][]int {
+			return indexTerms(text, folded)
+		},
+		node: func(match []string) dom.Node {
+			return wrap(match[0])
+		},
+	}.replaceAll(tree, opts)
+}
+
+// indexTerms returns the locations of the non-overlapping occurrences of the folded
+// terms in text, preferring the earlier terms where they overlap.
+func indexTerms(text string, terms []string) [][]int {
+	folded, offsets := foldText(text)
+	var locs [][]int
+	for i := 0; i < len(folded); {
+		matched := false
+		for _, term := range terms {
+			if strings.HasPrefix(folded[i:], term) {
+				locs = append(locs, []int{offsets[i], offsets[i+len(term)]})
+				i += len(term)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			_, size := utf8.DecodeRuneInString(folded[i:])
+			i += size
+		}
+	}
+	return locs
+}
+
+// foldText returns text lower cased and without diacritics, and for every byte of it
+// (and one past the end), the offset into text of the character it came from.
+func foldText(text string) (string, []int) {
+	var sb strings.Builder
+	offsets := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		if unicode.Is(unicode.Mn, r) {
+			continue // combining marks, e.g. the accent of a decomposed "é"
+		}
+		r = unicode.ToLower(r)
+		folded, ok := foldedRunes[r]
+		if !ok {
+			folded = string(r)
+		}
+		sb.WriteString(folded)
+		for range []byte(folded) {
+			offsets = append(offsets, i)
+		}
+	}
+	return sb.String(), append(offsets, len(text))
+}
+
+// foldedRunes are the lower case letters with diacritics, and ligatures, and what
+// they fold into.
+var foldedRunes = func() map[rune]string {
+	m := map[rune]string{'æ': "ae", 'œ': "oe", 'ß': "ss", 'ĳ': "ij", 'þ': "th", 'ð': "d"}
+	for base, letters := range map[string]string{
+		"a": "àáâãäåāăąǎǻạảấầẩẫậắằẳẵặ",
+		"c": "çćĉċč",
+		"d": "ďđ",
+		"e": "èéêëēĕėęěẹẻẽếềểễệ",
+		"g": "ĝğġģ",
+		"h": "ĥħ",
+		"i": "ìíîïĩīĭįıǐỉị",
+		"j": "ĵ",
+		"k": "ķ",
+		"l": "ĺļľŀł",
+		"n": "ñńņňŉ",
+		"o": "òóôõöøōŏőǒơọỏốồổỗộớờởỡợ",
+		"r": "ŕŗř",
+		"s": "śŝşšș",
+		"t": "ţťŧț",
+		"u": "ùúûüũūŭůűųǔưụủứừửữự",
+		"w": "ŵ",
+		"y": "ýÿŷỳỵỷỹ",
+		"z": "źżž",
+	} {
+		for _, r := range letters {
+			m[r] = base
+		}
+	}
+	return m
+}()
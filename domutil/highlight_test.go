@@ -0,0 +1,83 @@
+package domutil_test
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		given dom.Node
+		terms []string
+		wrap  func(string) dom.Node
+		opts  []domutil.ReplaceOption
+		want  template.HTML
+	}{
+		{
+			name:  "case-insensitive, preserving the original casing",
+			given: dom.P(dom.Attrs(), dom.InnerText("Go, go, GO!")),
+			terms: []string{"go"},
+			want:  `<p><mark>Go</mark>, <mark>go</mark>, <mark>GO</mark>!</p>`,
+		},
+		{
+			name:  "ignoring diacritics, both ways",
+			given: dom.P(dom.Attrs(), dom.InnerText("Café crème at the CAFE, naïve Zoë")),
+			terms: []string{"cafe", "creme", "naive", "zoë"},
+			want:  `<p><mark>Café</mark> <mark>crème</mark> at the <mark>CAFE</mark>, <mark>naïve</mark> <mark>Zoë</mark></p>`,
+		},
+		{
+			name:  "decomposed diacritics",
+			given: dom.InnerText("Cafe\u0301 au lait"),
+			terms: []string{"café"},
+			want:  "<mark>Cafe\u0301</mark> au lait",
+		},
+		{
+			name:  "the longest of overlapping terms wins",
+			given: dom.InnerText("new york and new jersey"),
+			terms: []string{"new", "new york"},
+			want:  `<mark>new york</mark> and <mark>new</mark> jersey`,
+		},
+		{
+			name:  "whole words",
+			given: dom.InnerText("cat concatenate cat_2 (cat) Cat's"),
+			terms: []string{"cat"},
+			opts:  []domutil.ReplaceOption{domutil.WholeWords()},
+			want:  `<mark>cat</mark> concatenate cat_2 (<mark>cat</mark>) <mark>Cat</mark>&#39;s`,
+		},
+		{
+			name:  "never inside attribute values or tags",
+			given: dom.InnerHTML(`<a href="/go" title="Go">Let&#39;s GO</a><go>`),
+			terms: []string{"go"},
+			want:  `<a href="/go" title="Go">Let&#39;s <mark>GO</mark></a><go>`,
+		},
+		{
+			name:  "custom wrap and across inline elements",
+			given: dom.P(dom.Attrs(), dom.InnerText("hello "), dom.B(dom.Attrs(), dom.InnerText("Wor")), dom.InnerText("ld")),
+			terms: []string{"world"},
+			wrap: func(s string) dom.Node {
+				return dom.Span(dom.Attrs("class", "hit"), dom.InnerText(s))
+			},
+			opts: []domutil.ReplaceOption{domutil.AcrossInline()},
+			want: `<p>hello <span class="hit">World</span></p>`,
+		},
+		{
+			name:  "no terms",
+			given: dom.InnerText("hello world"),
+			terms: []string{"", " "},
+			want:  `hello world`,
+		},
+	}
+	for _, tt := range tests {
+		oldHTML := tt.given.HTML()
+		if got := domutil.Highlight(tt.given, tt.terms, tt.wrap, tt.opts...).HTML(); got != tt.want {
+			t.Errorf("%s:\ngot      %q\nbut want %q", tt.name, got, tt.want)
+		}
+		if oldHTML != tt.given.HTML() {
+			t.Errorf("%s: Highlight modified the given node", tt.name)
+		}
+	}
+}
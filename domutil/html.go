@@ -0,0 +1,82 @@
+package domutil
+
+import (
+	"html"
+	"strings"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/internal/htmltoken"
+)
+
+// textRanges returns the byte ranges of the text content in a fragment of html.
+// Tags, attribute values, comments and the contents of `<script>` and `<style>`
+// are not text content.
+func textRanges(s string) [][2]int {
+	var ranges [][2]int
+	for _, token := range htmltoken.Tokenize(s) {
+		if token.Kind == htmltoken.Text {
+			ranges = append(ranges, [2]int{token.Start, token.End})
+		}
+	}
+	return ranges
+}
+
+// unescapeText returns the text of html text content, and for every byte of the text
+// (and one past the end), its offset into raw. The bytes of a character reference,
+// e.g. `&amp;`, all have the offset of its `&`.
+func unescapeText(raw string) (string, []int) {
+	if strings.IndexByte(raw, '&') < 0 {
+		offsets := make([]int, len(raw)+1)
+		for i := range offsets {
+			offsets[i] = i
+		}
+		return raw, offsets
+	}
+
+	var sb strings.Builder
+	offsets := make([]int, 0, len(raw)+1)
+	for i := 0; i < len(raw); {
+		if raw[i] == '&' {
+			if end := strings.IndexByte(raw[i:], ';'); end > 1 && end <= 32 {
+				ref := raw[i : i+end+1]
+				if text := html.UnescapeString(ref); text != ref {
+					sb.WriteString(text)
+					for range []byte(text) {
+						offsets = append(offsets, i)
+					}
+					i += len(ref)
+					continue
+				}
+			}
+		}
+		sb.WriteByte(raw[i])
+		offsets = append(offsets, i)
+		i++
+	}
+	return sb.String(), append(offsets, len(raw))
+}
+
+// textContent returns the text of n without markup, like the DOM property of the
+// same name, but leaving out the contents of `<script>` and `<style>`.
+func textContent(n dom.Node) string {
+	var sb strings.Builder
+	Walk(n, Visitor{
+		Enter: func(c Cursor) WalkAction {
+			switch {
+			case isRawTextElement(c.Node.Name):
+				return SkipChildren
+			case c.Node.InnerHTML != "":
+				s := string(c.Node.InnerHTML)
+				for _, token := range htmltoken.Tokenize(s) {
+					if token.Kind == htmltoken.Text {
+						sb.WriteString(html.UnescapeString(s[token.Start:token.End]))
+					}
+				}
+			case c.Node.InnerText != "":
+				sb.WriteString(c.Node.InnerText)
+			}
+			return Continue
+		},
+	})
+	return sb.String()
+}
@@ -0,0 +1,197 @@
+package domutil
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// placeholder elements resolved by NumberReferences
+const (
+	footnoteElement     = "domutil-footnote"
+	footnoteListElement = "domutil-footnotes"
+	crossRefElement     = "domutil-ref"
+)
+
+// Footnote returns a footnote with the given content. NumberReferences replaces it
+// with a superscript number linking to the content in the footnote list, e.g.
+//
+//	<sup id="fnref-1"><a href="#fn-1" role="doc-noteref">1</a></sup>
+func Footnote(content ...dom.Node) dom.Node {
+	return dom.Element(footnoteElement, dom.Attrs(), content...)
+}
+
+// FootnoteList marks where NumberReferences puts the footnotes before it, and after
+// any FootnoteList before it, e.g.
+//
+//	<ol class="footnotes" role="doc-endnotes">
+//	    <li id="fn-1">...<a href="#fnref-1" role="doc-backlink">↩︎</a></li>
+//	</ol>
+//
+// Footnotes after the last FootnoteList are added to the end of the tree.
+func FootnoteList() dom.Node {
+	return dom.Element(footnoteListElement, dom.Attrs())
+}
+
+// CrossRef returns a reference to the `<figure>` or `<table>` with the given id.
+// NumberReferences replaces it with a link reading e.g. "Figure 2", or "??" when
+// there is no numbered figure or table with that id.
+func CrossRef(id string) dom.Node {
+	return dom.Element(crossRefElement, dom.Attrs("href", "#"+id))
+}
+
+// ReferenceOptions configures NumberReferences. The zero value is ready to use.
+type ReferenceOptions struct {
+	// IDPrefix is added to the ids of footnotes and of the links to them, to keep
+	// them apart when there are several documents in a page.
+	IDPrefix string
+
+	// FigureLabel and TableLabel are the words before the number of a figure or a
+	// table. Default to "Figure" and "Table".
+	FigureLabel string
+	TableLabel  string
+}
+
+// NumberReferences returns tree with every Footnote, FootnoteList and CrossRef
+// replaced, numbering footnotes in the order they appear.
+//
+// Every `<figure>` with a `<figcaption>`, and `<table>` with a `<caption>`, is
+// numbered too, and the number added to the start of the caption, e.g.
+// "Figure 2: ". Figures and tables are numbered separately.
+//
+// Like ReplaceAll, the given tree is not modified.
+func NumberReferences(tree dom.Node, opts ReferenceOptions) dom.Node {
+	if opts.FigureLabel == "" {
+		opts.FigureLabel = "Figure"
+	}
+	if opts.TableLabel == "" {
+		opts.TableLabel = "Table"
+	}
+
+	// number the captions first, since a CrossRef may come before what it refers to,
+	// and the footnotes, since Transform reaches a footnote in a footnote first
+	var figures, tables, footnotes int
+	captions := map[string]string{} // label by pathKey of figure or table
+	labels := map[string]string{}   // label by id of figure or table
+	numbers := map[string]int{}     // number by pathKey of footnote
+	Walk(tree, Visitor{
+		Enter: func(c Cursor) WalkAction {
+			var label string
+			switch {
+			case c.Node.Name == footnoteElement:
+				footnotes++
+				numbers[pathKey(c.Path)] = footnotes
+				return Continue
+			case c.Node.Name == "figure" && captionIndex(c.Node, "figcaption") >= 0:
+				figures++
+				label = opts.FigureLabel + " " + strconv.Itoa(figures)
+			case c.Node.Name == "table" && captionIndex(c.Node, "caption") >= 0:
+				tables++
+				label = opts.TableLabel + " " + strconv.Itoa(tables)
+			default:
+				return Continue
+			}
+			captions[pathKey(c.Path)] = label
+			if id, ok := attrValue(c.Node, "id"); ok {
+				labels[id] = label
+			}
+			return Continue
+		},
+	})
+
+	var pending []footnote
+	newTree := Transform(tree, func(c Cursor) dom.Node {
+		switch c.Node.Name {
+		case footnoteElement:
+			number := numbers[pathKey(c.Path)]
+			n := strconv.Itoa(number)
+			id, refID := opts.IDPrefix+"fn-"+n, opts.IDPrefix+"fnref-"+n
+			content := append(append([]dom.Node{}, c.Node.Children...),
+				dom.InnerText(" "),
+				dom.A(dom.Attrs("href", "#"+refID, "role", "doc-backlink"), dom.InnerText("↩︎")),
+			)
+			pending = append(pending, footnote{number, dom.Li(dom.Attrs("id", id), content...)})
+			return dom.Sup(dom.Attrs("id", refID),
+				dom.A(dom.Attrs("href", "#"+id, "role", "doc-noteref"), dom.InnerText(n)),
+			)
+
+		case footnoteListElement:
+			list := footnoteList(pending)
+			pending = nil
+			return list
+
+		case crossRefElement:
+			href, _ := attrValue(c.Node, "href")
+			label, ok := labels[strings.TrimPrefix(href, "#")]
+			if !ok {
+				label = "??"
+			}
+			return dom.A(dom.Attrs("href", href), dom.InnerText(label))
+
+		case "figure", "table":
+			label, ok := captions[pathKey(c.Path)]
+			if !ok {
+				return c.Node
+			}
+			caption := "figcaption"
+			if c.Node.Name == "table" {
+				caption = "caption"
+			}
+			i := captionIndex(c.Node, caption)
+			c.Node.Children = append([]dom.Node{}, c.Node.Children...)
+			c.Node.Children[i] = prependText(c.Node.Children[i], label+": ")
+			return c.Node
+		}
+		return c.Node
+	})
+
+	if len(pending) > 0 {
+		newTree.Children = append(append([]dom.Node{}, newTree.Children...), footnoteList(pending))
+	}
+	return newTree
+}
+
+// footnote is the item of a numbered footnote in the footnote list.
+type footnote struct {
+	number int
+	item   dom.Node
+}
+
+// footnoteList returns the list of footnotes, in the order of their numbers.
+func footnoteList(footnotes []footnote) dom.Node {
+	if len(footnotes) == 0 {
+		return dom.Node{}
+	}
+	sort.Slice(footnotes, func(i, j int) bool { return footnotes[i].number < footnotes[j].number })
+	items := make([]dom.Node, 0, len(footnotes))
+	for _, f := range footnotes {
+		items = append(items, f.item)
+	}
+	return dom.Ol(dom.Attrs("class", "footnotes", "role", "doc-endnotes"), items...)
+}
+
+// captionIndex returns the index of the first child of n with the given name, or -1.
+func captionIndex(n dom.Node, name string) int {
+	for i, child := range n.Children {
+		if child.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// prependText returns n with text added before its content.
+func prependText(n dom.Node, text string) dom.Node {
+	content := n.Children
+	switch {
+	case n.InnerHTML != "":
+		content = []dom.Node{dom.InnerHTML(string(n.InnerHTML))}
+	case n.InnerText != "":
+		content = []dom.Node{dom.InnerText(n.InnerText)}
+	}
+	n.InnerHTML, n.InnerText = "", ""
+	n.Children = append([]dom.Node{dom.InnerText(text)}, content...)
+	return n
+}
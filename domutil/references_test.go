@@ -0,0 +1,122 @@
+package domutil_test
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+func TestNumberReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		given dom.Node
+		opts  domutil.ReferenceOptions
+		want  template.HTML
+	}{
+		{
+			name: "footnotes at each list",
+			given: dom.Article(dom.Attrs(),
+				dom.P(dom.Attrs(),
+					dom.InnerText("One"),
+					domutil.Footnote(dom.InnerText("First")),
+					dom.InnerText(" two"),
+					domutil.Footnote(dom.Em(dom.Attrs(), dom.InnerText("Second"))),
+				),
+				domutil.FootnoteList(),
+				dom.P(dom.Attrs(), dom.InnerText("Three"), domutil.Footnote(dom.InnerText("Third"))),
+				domutil.FootnoteList(),
+				domutil.FootnoteList(),
+			),
+			want: `<article>` +
+				`<p>One<sup id="fnref-1"><a href="#fn-1" role="doc-noteref">1</a></sup> two<sup id="fnref-2"><a href="#fn-2" role="doc-noteref">2</a></sup></p>` +
+				`<ol class="footnotes" role="doc-endnotes">` +
+				`<li id="fn-1">First <a href="#fnref-1" role="doc-backlink">↩︎</a></li>` +
+				`<li id="fn-2"><em>Second</em> <a href="#fnref-2" role="doc-backlink">↩︎</a></li>` +
+				`</ol>` +
+				`<p>Three<sup id="fnref-3"><a href="#fn-3" role="doc-noteref">3</a></sup></p>` +
+				`<ol class="footnotes" role="doc-endnotes">` +
+				`<li id="fn-3">Third <a href="#fnref-3" role="doc-backlink">↩︎</a></li>` +
+				`</ol>` +
+				`</article>`,
+		},
+		{
+			name: "footnotes without a list are added to the end",
+			given: dom.Div(dom.Attrs(),
+				dom.P(dom.Attrs(), dom.InnerText("One"), domutil.Footnote(dom.InnerText("First"))),
+			),
+			opts: domutil.ReferenceOptions{IDPrefix: "post1-"},
+			want: `<div>` +
+				`<p>One<sup id="post1-fnref-1"><a href="#post1-fn-1" role="doc-noteref">1</a></sup></p>` +
+				`<ol class="footnotes" role="doc-endnotes">` +
+				`<li id="post1-fn-1">First <a href="#post1-fnref-1" role="doc-backlink">↩︎</a></li>` +
+				`</ol>` +
+				`</div>`,
+		},
+		{
+			name: "a footnote in a footnote is numbered after it",
+			given: dom.Div(dom.Attrs(),
+				dom.P(dom.Attrs(),
+					dom.InnerText("One"),
+					domutil.Footnote(dom.InnerText("Outer"), domutil.Footnote(dom.InnerText("Inner"))),
+					domutil.Footnote(dom.InnerText("Last")),
+				),
+			),
+			want: `<div>` +
+				`<p>One<sup id="fnref-1"><a href="#fn-1" role="doc-noteref">1</a></sup><sup id="fnref-3"><a href="#fn-3" role="doc-noteref">3</a></sup></p>` +
+				`<ol class="footnotes" role="doc-endnotes">` +
+				`<li id="fn-1">Outer<sup id="fnref-2"><a href="#fn-2" role="doc-noteref">2</a></sup> <a href="#fnref-1" role="doc-backlink">↩︎</a></li>` +
+				`<li id="fn-2">Inner <a href="#fnref-2" role="doc-backlink">↩︎</a></li>` +
+				`<li id="fn-3">Last <a href="#fnref-3" role="doc-backlink">↩︎</a></li>` +
+				`</ol>` +
+				`</div>`,
+		},
+		{
+			name: "figures and tables",
+			given: dom.Article(dom.Attrs(),
+				dom.P(dom.Attrs(),
+					dom.InnerText("See "), domutil.CrossRef("arch"),
+					dom.InnerText(", "), domutil.CrossRef("sizes"),
+					dom.InnerText(" and "), domutil.CrossRef("missing"),
+				),
+				dom.Figure(dom.Attrs(), dom.Img(dom.Attrs("src", "logo.png"))),
+				dom.Figure(dom.Attrs("id", "intro"),
+					dom.Img(dom.Attrs("src", "intro.png")),
+					dom.Figcaption(dom.Attrs(), dom.InnerText("Intro")),
+				),
+				dom.Table(dom.Attrs("id", "sizes"), dom.Caption(dom.Attrs(), dom.InnerText("Sizes"))),
+				dom.Figure(dom.Attrs("id", "arch"),
+					dom.Figcaption(dom.Attrs(), dom.InnerHTML("<b>Architecture</b>")),
+				),
+			),
+			want: `<article>` +
+				`<p>See <a href="#arch">Figure 2</a>, <a href="#sizes">Table 1</a> and <a href="#missing">??</a></p>` +
+				`<figure><img src="logo.png"/></figure>` +
+				`<figure id="intro"><img src="intro.png"/><figcaption>Figure 1: Intro</figcaption></figure>` +
+				`<table id="sizes"><caption>Table 1: Sizes</caption></table>` +
+				`<figure id="arch"><figcaption>Figure 2: <b>Architecture</b></figcaption></figure>` +
+				`</article>`,
+		},
+		{
+			name: "labels",
+			given: dom.Figure(dom.Attrs(),
+				dom.Figcaption(dom.Attrs(), dom.InnerText("Logo")),
+			),
+			opts: domutil.ReferenceOptions{FigureLabel: "Abbildung"},
+			want: `<figure><figcaption>Abbildung 1: Logo</figcaption></figure>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.given.HTML()
+			if got := domutil.NumberReferences(tt.given, tt.opts).HTML(); got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+			if got := tt.given.HTML(); got != before {
+				t.Errorf("given node was modified\ngot      %q\nbut want %q", got, before)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package domutil
 
 import (
-	"html/template"
+	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/choonkeat/dom-go"
 )
@@ -11,44 +13,402 @@ import (
 // the original content. e.g. replacing all occurrences of "{your email}" with a
 // `<a href="mailto:...">...</a>` html.
 //
-// It looks for all occurrences of matchText as InnerText, or escaped(matchText) as InnerHTML,
-// or recursively in all children of the target node.
+// It looks for all occurrences of matchText in InnerText, or in the text content of InnerHTML,
+// or recursively in all children of the target node. Inside InnerHTML, tags, attribute values,
+// comments and the contents of `<script>` and `<style>` are left alone, and text is matched
+// unescaped, i.e. "Tom & Jerry" matches `Tom &amp; Jerry`.
+//
+// Text split across sibling nodes, e.g. `dom.InnerText("wor"), dom.InnerText("ld")`, is only
+// matched with the AcrossSiblings or AcrossInline options.
 //
 // If we want to search and replace html, we can just use strings.ReplaceAll(target.HTML(), ...)
-func ReplaceAll(target dom.Node, matchText string, node dom.Node) dom.Node {
+func ReplaceAll(target dom.Node, matchText string, node dom.Node, opts ...ReplaceOption) dom.Node {
+	return ReplaceAllFunc(target, matchText, func(string) dom.Node {
+		return node
+	}, opts...)
+}
+
+// ReplaceAllFunc is like ReplaceAll, but every occurrence of matchText is replaced
+// with a new node from fn, e.g. a `:smile:` with an emoji `<img>`.
+func ReplaceAllFunc(target dom.Node, matchText string, fn func(match string) dom.Node, opts ...ReplaceOption) dom.Node {
+	return replacer{
+		find: func(text string) [][]int {
+			return indexAll(text, matchText)
+		},
+		node: func(match []string) dom.Node {
+			return fn(match[0])
+		},
+	}.replaceAll(target, opts)
+}
+
+// ReplaceAllRegexp is like ReplaceAll, but replaces every match of re with a new node
+// from fn, which is given the match followed by its capture groups, like
+// regexp.FindStringSubmatch. e.g. replacing `#(\d+)` with a link to that ticket.
+//
+// In InnerHTML, only the text between tags is matched, as text: fn is given
+// `Tom & Jerry` and not `Tom &amp; Jerry`. Empty matches are ignored.
+func ReplaceAllRegexp(target dom.Node, re *regexp.Regexp, fn func(match []string) dom.Node, opts ...ReplaceOption) dom.Node {
+	return replacer{
+		find: func(text string) [][]int {
+			return re.FindAllStringSubmatchIndex(text, -1)
+		},
+		node: fn,
+	}.replaceAll(target, opts)
+}
+
+// ReplaceOption changes how ReplaceAll, ReplaceAllFunc and ReplaceAllRegexp match text.
+type ReplaceOption func(*replacer)
+
+// AcrossSiblings also matches text that spans adjacent InnerText siblings, e.g.
+// "hello" in `dom.InnerText("hel"), dom.InnerText("lo")`.
+func AcrossSiblings() ReplaceOption {
+	return func(r *replacer) {
+		r.acrossSiblings = true
+	}
+}
+
+// AcrossInline is like AcrossSiblings, but also matches text that spans inline formatting
+// elements, e.g. "world" in `dom.InnerText("wor"), dom.B(dom.Attrs(), dom.InnerText("ld"))`.
+//
+// The formatting elements are split around the replacement node, e.g. replacing "lo wo" in
+// `hello <b>world</b>` gives `hel<replacement/><b>rld</b>`. A replacement within a single
+// element stays inside it.
+func AcrossInline() ReplaceOption {
+	return func(r *replacer) {
+		r.acrossSiblings = true
+		r.acrossInline = true
+	}
+}
+
+// WholeWords only matches text that is not part of a longer word, e.g. "cat" does not
+// match in "concatenate". Words are letters, digits and underscores.
+func WholeWords() ReplaceOption {
+	return func(r *replacer) {
+		r.wholeWords = true
+	}
+}
+
+// inlineFormatting are the elements that AcrossInline matches across; `<a>` is not one,
+// lest we split a link or nest a replacement link inside it.
+var inlineFormatting = map[string]bool{
+	"abbr": true, "b": true, "bdi": true, "bdo": true, "cite": true, "code": true, "data": true,
+	"del": true, "dfn": true, "em": true, "i": true, "ins": true, "kbd": true, "mark": true,
+	"q": true, "s": true, "samp": true, "small": true, "span": true, "strong": true,
+	"sub": true, "sup": true, "time": true, "u": true, "var": true,
+}
+
+// replacer replaces matches in InnerText and InnerHTML with nodes.
+type replacer struct {
+	// find returns the locations of the matches in text, and their submatches, like
+	// regexp.FindAllStringSubmatchIndex does
+	find func(text string) [][]int
+
+	// node returns the node to replace a match with, given the match and its submatches
+	node func(match []string) dom.Node
+
+	acrossSiblings bool // see AcrossSiblings
+	acrossInline   bool // see AcrossInline
+	wholeWords     bool // see WholeWords
+}
+
+// matches returns the locations of the non-empty matches in text.
+func (r replacer) matches(text string) [][]int {
+	var locs [][]int
+	for _, loc := range r.find(text) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		if r.wholeWords && (endsWithWordChar(text[:loc[0]]) || startsWithWordChar(text[loc[1]:])) {
+			continue
+		}
+		locs = append(locs, loc)
+	}
+	return locs
+}
+
+func startsWithWordChar(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size > 0 && isWordChar(r)
+}
+
+func endsWithWordChar(s string) bool {
+	r, size := utf8.DecodeLastRuneInString(s)
+	return size > 0 && isWordChar(r)
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+func (r replacer) replaceAll(target dom.Node, opts []ReplaceOption) dom.Node {
+	for _, opt := range opts {
+		opt(&r)
+	}
+	if r.acrossSiblings {
+		return r.replaceAcross(target)
+	}
+	return Transform(target, func(c Cursor) dom.Node {
+		return r.replaceContent(c.Node)
+	})
+}
+
+// replaceContent replaces the matches in the InnerText or InnerHTML of n.
+func (r replacer) replaceContent(n dom.Node) dom.Node {
+	var parts []dom.Node
 	switch {
-	case target.InnerHTML != "":
-		parts := strings.Split(string(target.InnerHTML), template.HTMLEscapeString(matchText))
+	case n.InnerHTML != "":
+		parts = r.splitHTML(string(n.InnerHTML))
+	case n.InnerText != "":
+		parts = r.splitText(n.InnerText)
+	}
+	if parts == nil {
+		return n
+	}
+	if n.Name == "" {
+		return Join(parts...)
+	}
+	n.InnerHTML, n.InnerText, n.Children = "", "", parts
+	return n
+}
+
+// replaceAcross is replaceAll, but matching runs of adjacent text siblings as one text.
+func (r replacer) replaceAcross(n dom.Node) dom.Node {
+	if r.isText(n) {
+		parts := r.replaceRun([]dom.Node{n})
 		if len(parts) == 1 {
-			return target
+			return parts[0]
+		}
+		return Join(parts...)
+	}
+	if len(n.Children) == 0 {
+		return r.replaceContent(n)
+	}
+	children := make([]dom.Node, 0, len(n.Children))
+	for i := 0; i < len(n.Children); {
+		j := i
+		for j < len(n.Children) && r.isText(n.Children[j]) {
+			j++
+		}
+		if j > i {
+			children = append(children, r.replaceRun(n.Children[i:j])...)
+			i = j
+			continue
+		}
+		children = append(children, r.replaceAcross(n.Children[i]))
+		i++
+	}
+	n.Children = children
+	return n
+}
+
+// isText reports whether n renders as nothing but text, possibly with inline
+// formatting when matching across it.
+func (r replacer) isText(n dom.Node) bool {
+	if n.Name != "" && !(r.acrossInline && inlineFormatting[strings.ToLower(n.Name)]) {
+		return false
+	}
+	if n.InnerHTML != "" {
+		return false
+	}
+	if n.InnerText != "" {
+		return true
+	}
+	for _, child := range n.Children {
+		if !r.isText(child) {
+			return false
 		}
-		newParts := make([]dom.Node, 0, len(parts)+len(parts)-1)
-		for i, part := range parts {
-			if i != 0 {
-				newParts = append(newParts, node)
+	}
+	return len(n.Children) > 0
+}
+
+// runPiece is a piece of the text of a run of siblings, or a replacement node.
+type runPiece struct {
+	text        string
+	replacement *dom.Node
+	wrappers    []int // indexes into the run's wrappers, outermost first
+}
+
+// replaceRun replaces the matches in the text of a run of siblings, see isText.
+func (r replacer) replaceRun(run []dom.Node) []dom.Node {
+	// flatten the run into text leaves, each with the elements wrapping it
+	var wrappers []dom.Node
+	var leaves []runPiece
+	var flatten func(n dom.Node, chain []int)
+	flatten = func(n dom.Node, chain []int) {
+		if n.Name != "" || len(n.Children) > 0 {
+			wrapper := n
+			wrapper.InnerText, wrapper.Children = "", nil
+			wrappers = append(wrappers, wrapper)
+			chain = append(chain[:len(chain):len(chain)], len(wrappers)-1)
+		}
+		if n.InnerText != "" {
+			leaves = append(leaves, runPiece{text: n.InnerText, wrappers: chain})
+			return
+		}
+		for _, child := range n.Children {
+			flatten(child, chain)
+		}
+	}
+	var sb strings.Builder
+	for _, n := range run {
+		flatten(n, nil)
+	}
+	for _, leaf := range leaves {
+		sb.WriteString(leaf.text)
+	}
+	text := sb.String()
+
+	locs := r.matches(text)
+	if len(locs) == 0 {
+		return run
+	}
+
+	// cut the leaves around the matches, and put each replacement in the innermost
+	// element enclosing the whole match
+	var pieces []runPiece
+	leafStart := 0
+	for li, leaf := range leaves {
+		leafEnd := leafStart + len(leaf.text)
+		cur := leafStart
+		for _, loc := range locs {
+			if loc[1] <= cur || loc[0] >= leafEnd {
+				continue
+			}
+			if loc[0] > cur {
+				pieces = append(pieces, runPiece{text: text[cur:loc[0]], wrappers: leaf.wrappers})
+			}
+			if loc[0] >= leafStart {
+				node := r.node(submatches(text, loc))
+				pieces = append(pieces, runPiece{replacement: &node, wrappers: commonWrappers(leaves[li:], leafStart, loc[1])})
+			}
+			cur = loc[1]
+			if cur > leafEnd {
+				cur = leafEnd
 			}
-			newParts = append(newParts, dom.InnerHTML(part))
 		}
-		return Join(newParts...)
-	case target.InnerText != "":
-		parts := strings.Split(string(target.InnerText), matchText)
-		if len(parts) == 1 {
-			return target
+		if cur < leafEnd {
+			pieces = append(pieces, runPiece{text: text[cur:leafEnd], wrappers: leaf.wrappers})
+		}
+		leafStart = leafEnd
+	}
+	return regroup(pieces, 0, wrappers)
+}
+
+// commonWrappers returns the wrappers shared by the leaves, the first starting at offset
+// start, that hold the text up to offset end.
+func commonWrappers(leaves []runPiece, start, end int) []int {
+	common := leaves[0].wrappers
+	for i := 1; i < len(leaves); i++ {
+		start += len(leaves[i-1].text)
+		if start >= end {
+			break
 		}
-		newParts := make([]dom.Node, 0, len(parts)+len(parts)-1)
-		for i, part := range parts {
-			if i != 0 {
-				newParts = append(newParts, node)
+		n := 0
+		for n < len(common) && n < len(leaves[i].wrappers) && common[n] == leaves[i].wrappers[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return common
+}
+
+// regroup rebuilds the nodes of pieces, nesting consecutive pieces that share a
+// wrapper at depth inside a copy of it.
+func regroup(pieces []runPiece, depth int, wrappers []dom.Node) []dom.Node {
+	var nodes []dom.Node
+	for i := 0; i < len(pieces); {
+		if len(pieces[i].wrappers) <= depth {
+			if pieces[i].replacement != nil {
+				nodes = append(nodes, *pieces[i].replacement)
+			} else {
+				nodes = append(nodes, dom.InnerText(pieces[i].text))
+			}
+			i++
+			continue
+		}
+		w := pieces[i].wrappers[depth]
+		j := i + 1
+		for j < len(pieces) && len(pieces[j].wrappers) > depth && pieces[j].wrappers[depth] == w {
+			j++
+		}
+		wrapper := wrappers[w]
+		wrapper.Children = regroup(pieces[i:j], depth+1, wrappers)
+		nodes = append(nodes, wrapper)
+		i = j
+	}
+	return nodes
+}
+
+// splitText returns text with the matches replaced, as InnerText and replacement
+// nodes, or nil if nothing matched.
+func (r replacer) splitText(text string) []dom.Node {
+	var parts []dom.Node
+	start := 0
+	for _, loc := range r.matches(text) {
+		if loc[0] > start {
+			parts = append(parts, dom.InnerText(text[start:loc[0]]))
+		}
+		parts = append(parts, r.node(submatches(text, loc)))
+		start = loc[1]
+	}
+	if parts == nil {
+		return nil
+	}
+	if start < len(text) {
+		parts = append(parts, dom.InnerText(text[start:]))
+	}
+	return parts
+}
+
+// splitHTML returns s with the matches in its text content replaced, as InnerHTML
+// and replacement nodes, or nil if nothing matched.
+func (r replacer) splitHTML(s string) []dom.Node {
+	var parts []dom.Node
+	start := 0
+	for _, textRange := range textRanges(s) {
+		raw := s[textRange[0]:textRange[1]]
+		text, offsets := unescapeText(raw)
+		for _, loc := range r.matches(text) {
+			matchStart, matchEnd := textRange[0]+offsets[loc[0]], textRange[0]+offsets[loc[1]]
+			if matchStart > start {
+				parts = append(parts, dom.InnerHTML(s[start:matchStart]))
 			}
-			newParts = append(newParts, dom.InnerText(part))
+			parts = append(parts, r.node(submatches(text, loc)))
+			start = matchEnd
 		}
-		return Join(newParts...)
-	default:
-		newChildren := make([]dom.Node, 0, len(target.Children))
-		for _, child := range target.Children {
-			newChildren = append(newChildren, ReplaceAll(child, matchText, node))
+	}
+	if parts == nil {
+		return nil
+	}
+	if start < len(s) {
+		parts = append(parts, dom.InnerHTML(s[start:]))
+	}
+	return parts
+}
+
+// submatches returns the strings of text at loc, like regexp.FindStringSubmatch.
+func submatches(text string, loc []int) []string {
+	match := make([]string, len(loc)/2)
+	for i := range match {
+		if loc[2*i] >= 0 {
+			match[i] = text[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return match
+}
+
+// indexAll returns the locations of every non-overlapping occurrence of substr in s.
+func indexAll(s, substr string) [][]int {
+	if substr == "" {
+		return nil
+	}
+	var locs [][]int
+	for start := 0; ; {
+		i := strings.Index(s[start:], substr)
+		if i < 0 {
+			return locs
 		}
-		target.Children = newChildren
+		locs = append(locs, []int{start + i, start + i + len(substr)})
+		start += i + len(substr)
 	}
-	return target
 }
@@ -1,7 +1,9 @@
 package domutil_test
 
 import (
+	"fmt"
 	"html/template"
+	"regexp"
 	"testing"
 
 	"github.com/choonkeat/dom-go"
@@ -35,6 +37,48 @@ func TestReplaceAll(t *testing.T) {
 			match: "<strong>world</strong>",
 			want:  `hello &lt;em&gt;&amp;lt;strong&amp;gt;world&amp;lt;/strong&amp;gt;!&lt;/em&gt; <b class="text-xs">universe</b>!`,
 		},
+		{
+			// not inside attribute values, even with a quoted `>`
+			given: dom.InnerHTML(`<a title="world" data-x='a > world'>hello world</a>`),
+			match: "world",
+			want:  `<a title="world" data-x='a > world'>hello <b class="text-xs">universe</b></a>`,
+		},
+		{
+			// not inside tags
+			given: dom.InnerHTML(`<world>world</world><br/>`),
+			match: "world",
+			want:  `<world><b class="text-xs">universe</b></world><br/>`,
+		},
+		{
+			// not inside comments
+			given: dom.InnerHTML(`<!-- hello world -->world<!-->`),
+			match: "world",
+			want:  `<!-- hello world --><b class="text-xs">universe</b><!-->`,
+		},
+		{
+			// not inside script
+			given: dom.InnerHTML(`<script>var s = "<b>world</b>";</script><SCRIPT type="module">world</SCRIPT>world`),
+			match: "world",
+			want:  `<script>var s = "<b>world</b>";</script><SCRIPT type="module">world</SCRIPT><b class="text-xs">universe</b>`,
+		},
+		{
+			// not inside style
+			given: dom.InnerHTML(`<style>.world::after { content: "<p>world" }</style><p>world</p>`),
+			match: "world",
+			want:  `<style>.world::after { content: "<p>world" }</style><p><b class="text-xs">universe</b></p>`,
+		},
+		{
+			// a `<` that does not start markup is text
+			given: dom.InnerHTML(`1 < 2 world`),
+			match: "< 2 world",
+			want:  `1 <b class="text-xs">universe</b>`,
+		},
+		{
+			// elements with InnerHTML keep their tag and attributes
+			given: dom.Node{Name: "p", Attributes: dom.Attrs("title", "world"), InnerHTML: "hello <i>world</i>"},
+			match: "world",
+			want:  `<p title="world">hello <i><b class="text-xs">universe</b></i></p>`,
+		},
 		{
 			// we recursively look into all children of the target node
 			given: dom.Div(
@@ -84,3 +128,149 @@ func TestReplaceAll(t *testing.T) {
 		}
 	}
 }
+
+func TestReplaceAllRegexp(t *testing.T) {
+	ticketLink := func(match []string) dom.Node {
+		return dom.A(dom.Attrs("href", "/tickets/"+match[1]), dom.InnerText(match[0]))
+	}
+
+	tests := []struct {
+		given dom.Node
+		re    *regexp.Regexp
+		fn    func(match []string) dom.Node
+		want  template.HTML
+	}{
+		{
+			given: dom.P(dom.Attrs(), dom.InnerText("fixed in #1234 and #56, not #x")),
+			re:    regexp.MustCompile(`#(\d+)`),
+			fn:    ticketLink,
+			want:  `<p>fixed in <a href="/tickets/1234">#1234</a> and <a href="/tickets/56">#56</a>, not #x</p>`,
+		},
+		{
+			// only text between tags is matched, and it is matched unescaped
+			given: dom.InnerHTML(`<a title="#1">see</a> &lt;#2&gt; <b>#3</b>`),
+			re:    regexp.MustCompile(`<?#(\d+)>?`),
+			fn:    ticketLink,
+			want:  `<a title="#1">see</a> <a href="/tickets/2">&lt;#2&gt;</a> <b><a href="/tickets/3">#3</a></b>`,
+		},
+		{
+			// elements keep their name and attributes
+			given: dom.Node{Name: "li", Attributes: dom.Attrs("class", "mention"), InnerText: "thanks @alice & @bob"},
+			re:    regexp.MustCompile(`@(?P<user>\w+)`),
+			fn: func(match []string) dom.Node {
+				return dom.A(dom.Attrs("href", "/users/"+match[1]), dom.InnerText(match[0]))
+			},
+			want: `<li class="mention">thanks <a href="/users/alice">@alice</a> &amp; <a href="/users/bob">@bob</a></li>`,
+		},
+		{
+			// empty matches are ignored
+			given: dom.InnerText("abc"),
+			re:    regexp.MustCompile(`x*`),
+			fn:    ticketLink,
+			want:  `abc`,
+		},
+	}
+	for _, tt := range tests {
+		oldHTML := tt.given.HTML()
+		if got := domutil.ReplaceAllRegexp(tt.given, tt.re, tt.fn).HTML(); got != tt.want {
+			t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+		}
+		if oldHTML != tt.given.HTML() {
+			t.Errorf("ReplaceAllRegexp modified the given node")
+		}
+	}
+}
+
+func TestReplaceAllFunc(t *testing.T) {
+	given := dom.Div(dom.Attrs(),
+		dom.InnerText("hi :smile: there :smile:"),
+		dom.InnerHTML("<em>:smile:</em>"),
+	)
+	count := 0
+	got := domutil.ReplaceAllFunc(given, ":smile:", func(match string) dom.Node {
+		count++
+		return dom.Img(dom.Attrs("src", "/smile.png", "alt", match, "id", fmt.Sprintf("smile%d", count)))
+	}).HTML()
+	want := template.HTML(`<div>hi <img src="/smile.png" alt=":smile:" id="smile1"/> there <img src="/smile.png" alt=":smile:" id="smile2"/><em><img src="/smile.png" alt=":smile:" id="smile3"/></em></div>`)
+	if got != want {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+}
+
+func TestReplaceAllAcross(t *testing.T) {
+	// <b class="text-xs">universe</b>
+	replaceNode := dom.B(dom.Attrs("class", "text-xs"), dom.InnerText("universe"))
+
+	tests := []struct {
+		name  string
+		given dom.Node
+		match string
+		opts  []domutil.ReplaceOption
+		want  template.HTML
+	}{
+		{
+			name:  "without options, siblings are not matched together",
+			given: dom.P(dom.Attrs(), dom.InnerText("hello wor"), dom.InnerText("ld")),
+			match: "world",
+			want:  `<p>hello world</p>`,
+		},
+		{
+			name:  "across siblings",
+			given: dom.P(dom.Attrs(), dom.InnerText("hello wor"), dom.InnerText("l"), dom.InnerText("d!")),
+			match: "world",
+			opts:  []domutil.ReplaceOption{domutil.AcrossSiblings()},
+			want:  `<p>hello <b class="text-xs">universe</b>!</p>`,
+		},
+		{
+			name:  "across siblings, but not inline elements",
+			given: dom.P(dom.Attrs(), dom.InnerText("hello "), dom.B(dom.Attrs(), dom.InnerText("wor")), dom.InnerText("ld world")),
+			match: "world",
+			opts:  []domutil.ReplaceOption{domutil.AcrossSiblings()},
+			want:  `<p>hello <b>wor</b>ld <b class="text-xs">universe</b></p>`,
+		},
+		{
+			name:  "across inline elements",
+			given: dom.P(dom.Attrs(), dom.InnerText("hello "), dom.B(dom.Attrs(), dom.InnerText("wor")), dom.InnerText("ld")),
+			match: "world",
+			opts:  []domutil.ReplaceOption{domutil.AcrossInline()},
+			want:  `<p>hello <b class="text-xs">universe</b></p>`,
+		},
+		{
+			name:  "formatting is split around the replacement",
+			given: dom.P(dom.Attrs(), dom.InnerText("hello"), dom.Em(dom.Attrs("class", "x"), dom.InnerText(" wo"), dom.Strong(dom.Attrs(), dom.InnerText("rl"))), dom.InnerText("d")),
+			match: "lo wor",
+			opts:  []domutil.ReplaceOption{domutil.AcrossInline()},
+			want:  `<p>hel<b class="text-xs">universe</b><em class="x"><strong>l</strong></em>d</p>`,
+		},
+		{
+			name:  "a match within one element stays inside it",
+			given: dom.P(dom.Attrs(), dom.InnerText("say "), dom.Em(dom.Attrs(), dom.InnerText("hello "), dom.Strong(dom.Attrs(), dom.InnerText("wor")), dom.InnerText("ld!"))),
+			match: "world",
+			opts:  []domutil.ReplaceOption{domutil.AcrossInline()},
+			want:  `<p>say <em>hello <b class="text-xs">universe</b>!</em></p>`,
+		},
+		{
+			name:  "runs are broken by other elements and InnerHTML",
+			given: dom.Div(dom.Attrs(), dom.InnerText("wor"), dom.A(dom.Attrs(), dom.InnerText("ld")), dom.InnerText("wor"), dom.InnerHTML("ld"), dom.P(dom.Attrs(), dom.InnerText("wo"), dom.InnerText("rld"))),
+			match: "world",
+			opts:  []domutil.ReplaceOption{domutil.AcrossInline()},
+			want:  `<div>wor<a>ld</a>world<p><b class="text-xs">universe</b></p></div>`,
+		},
+		{
+			name:  "fragments of text",
+			given: domutil.Join(dom.InnerText("hello wo"), domutil.Join(dom.InnerText("r"), dom.InnerText("ld"))),
+			match: "world",
+			opts:  []domutil.ReplaceOption{domutil.AcrossSiblings()},
+			want:  `hello <b class="text-xs">universe</b>`,
+		},
+	}
+	for _, tt := range tests {
+		oldHTML := tt.given.HTML()
+		if got := domutil.ReplaceAll(tt.given, tt.match, replaceNode, tt.opts...).HTML(); got != tt.want {
+			t.Errorf("%s:\ngot      %q\nbut want %q", tt.name, got, tt.want)
+		}
+		if oldHTML != tt.given.HTML() {
+			t.Errorf("%s: ReplaceAll modified the given node", tt.name)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package domutil
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// Selection is the elements of a tree that matched a selector, see Select.
+//
+// Like ReplaceAll, the methods changing the elements return a Selection over a
+// new tree, and leave the tree they were called on untouched.
+//
+// Example:
+//
+//	page = domutil.Select(page, "a[href^=http]").SetAttr("target", "_blank").Tree()
+type Selection struct {
+	tree  dom.Node
+	paths [][]int // see Cursor.Path
+	err   error
+}
+
+// Select returns the elements of tree matching the CSS selector. If the selector
+// cannot be compiled, the Selection is empty and Err returns why.
+func Select(tree dom.Node, selector string) Selection {
+	s, err := Compile(selector)
+	if err != nil {
+		return Selection{tree: tree, err: err}
+	}
+	return s.Select(tree)
+}
+
+// Select returns the elements of tree matching the selector.
+func (s *Selector) Select(tree dom.Node) Selection {
+	var paths [][]int
+	for _, el := range s.match(tree) {
+		paths = append(paths, el.path)
+	}
+	return Selection{tree: tree, paths: paths}
+}
+
+// Err returns the error of compiling the selector given to Select, if any.
+func (s Selection) Err() error {
+	return s.err
+}
+
+// Tree returns the whole tree the selection is in.
+func (s Selection) Tree() dom.Node {
+	return s.tree
+}
+
+// Len returns the number of selected elements.
+func (s Selection) Len() int {
+	return len(s.paths)
+}
+
+// Nodes returns the selected elements, in the order they are rendered.
+func (s Selection) Nodes() []dom.Node {
+	var nodes []dom.Node
+	for _, path := range s.paths {
+		if node, ok := nodeAt(s.tree, path); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Map replaces every selected element with fn(element), the innermost first. The
+// selection is then of the replacements, but not of those that the fn of a selected
+// ancestor has replaced in turn.
+func (s Selection) Map(fn func(dom.Node) dom.Node) Selection {
+	if len(s.paths) == 0 {
+		return s
+	}
+	selected := s.pathSet()
+	replacements := make(map[string]dom.Node, len(s.paths))
+	s.tree = Transform(s.tree, func(c Cursor) dom.Node {
+		key := pathKey(c.Path)
+		if !selected[key] {
+			return c.Node
+		}
+		replacement := fn(c.Node)
+		replacements[key] = replacement
+		return replacement
+	})
+
+	paths := make([][]int, 0, len(s.paths))
+	for _, path := range s.paths {
+		if hasSelectedAncestor(selected, path) {
+			node, ok := nodeAt(s.tree, path)
+			if !ok || !reflect.DeepEqual(node, replacements[pathKey(path)]) {
+				continue
+			}
+		}
+		paths = append(paths, path)
+	}
+	s.paths = paths
+	return s
+}
+
+// AddClass adds the class names that the selected elements do not already have.
+func (s Selection) AddClass(names ...string) Selection {
+	return s.Map(func(n dom.Node) dom.Node {
+		value, _ := attrValue(n, "class")
+		classes := strings.Fields(value)
+		for _, name := range names {
+			if !containsWord(classes, name) {
+				classes = append(classes, name)
+			}
+		}
+		return n.SetAttr("class", strings.Join(classes, " "))
+	})
+}
+
+// RemoveClass removes the class names from the selected elements.
+func (s Selection) RemoveClass(names ...string) Selection {
+	return s.Map(func(n dom.Node) dom.Node {
+		value, ok := attrValue(n, "class")
+		if !ok {
+			return n
+		}
+		var classes []string
+		for _, class := range strings.Fields(value) {
+			if !containsWord(names, class) {
+				classes = append(classes, class)
+			}
+		}
+		return n.SetAttr("class", strings.Join(classes, " "))
+	})
+}
+
+// SetAttr sets the attribute on the selected elements, see dom.Node.SetAttr.
+func (s Selection) SetAttr(name, value string) Selection {
+	return s.Map(func(n dom.Node) dom.Node {
+		return n.SetAttr(name, value)
+	})
+}
+
+// RemoveAttr removes the attribute from the selected elements.
+func (s Selection) RemoveAttr(name string) Selection {
+	return s.Map(func(n dom.Node) dom.Node {
+		attrs := make([]dom.Attribute, 0, len(n.Attributes))
+		for _, attr := range n.Attributes {
+			if !strings.EqualFold(attr.Name, name) {
+				attrs = append(attrs, attr)
+			}
+		}
+		n.Attributes = attrs
+		return n
+	})
+}
+
+// ReplaceWith replaces the selected elements with node. The selection is then of
+// the replacements.
+func (s Selection) ReplaceWith(node dom.Node) Selection {
+	return s.Map(func(dom.Node) dom.Node {
+		return node
+	})
+}
+
+// Remove removes the selected elements, and their children, from the tree. The
+// selection is then empty. Removing the root leaves an empty tree.
+func (s Selection) Remove() Selection {
+	if len(s.paths) == 0 {
+		return s
+	}
+	selected := s.pathSet()
+	s.tree = Transform(s.tree, func(c Cursor) dom.Node {
+		if len(c.Path) == 0 && selected[pathKey(c.Path)] {
+			return dom.Node{}
+		}
+		var children []dom.Node
+		for i, child := range c.Node.Children {
+			if !selected[pathKey(append(c.Path, i))] {
+				children = append(children, child)
+			}
+		}
+		if len(children) != len(c.Node.Children) {
+			c.Node.Children = children
+		}
+		return c.Node
+	})
+	s.paths = nil
+	return s
+}
+
+// Wrap replaces every selected element with a copy of wrapper, which has the element
+// appended to its children. The selection is still of the wrapped elements.
+func (s Selection) Wrap(wrapper dom.Node) Selection {
+	if len(s.paths) == 0 {
+		return s
+	}
+	selected := s.pathSet()
+	s.tree = Transform(s.tree, func(c Cursor) dom.Node {
+		if !selected[pathKey(c.Path)] {
+			return c.Node
+		}
+		w := wrapper
+		w.Children = append(append(make([]dom.Node, 0, len(wrapper.Children)+1), wrapper.Children...), c.Node)
+		return w
+	})
+
+	// every selected element, including the ancestors of another, is now one level deeper
+	paths := make([][]int, 0, len(s.paths))
+	for _, path := range s.paths {
+		var newPath []int
+		for i := 0; i <= len(path); i++ {
+			if selected[pathKey(path[:i])] {
+				newPath = append(newPath, len(wrapper.Children))
+			}
+			if i < len(path) {
+				newPath = append(newPath, path[i])
+			}
+		}
+		paths = append(paths, newPath)
+	}
+	s.paths = paths
+	return s
+}
+
+func (s Selection) pathSet() map[string]bool {
+	set := make(map[string]bool, len(s.paths))
+	for _, path := range s.paths {
+		set[pathKey(path)] = true
+	}
+	return set
+}
+
+// hasSelectedAncestor reports whether an ancestor of the element at path is selected.
+func hasSelectedAncestor(selected map[string]bool, path []int) bool {
+	for i := range path {
+		if selected[pathKey(path[:i])] {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeAt returns the node at path in tree, and whether there is one.
+func nodeAt(tree dom.Node, path []int) (dom.Node, bool) {
+	for _, i := range path {
+		if i < 0 || i >= len(tree.Children) {
+			return dom.Node{}, false
+		}
+		tree = tree.Children[i]
+	}
+	return tree, true
+}
+
+// pathKey returns a string that is unique to path, for use as a map key.
+func pathKey(path []int) string {
+	var sb strings.Builder
+	for _, i := range path {
+		sb.WriteString(strconv.Itoa(i))
+		sb.WriteByte('/')
+	}
+	return sb.String()
+}
@@ -0,0 +1,161 @@
+package domutil_test
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+func TestSelection(t *testing.T) {
+	given := dom.Div(dom.Attrs(),
+		dom.Table(dom.Attrs(),
+			dom.Tr(dom.Attrs(),
+				dom.Td(dom.Attrs("class", "numeric"), dom.InnerText("1")),
+				dom.Td(dom.Attrs("class", "numeric right"), dom.InnerText("2")),
+			),
+		),
+		dom.Script(dom.Attrs(), dom.InnerText("alert(1)")),
+		dom.P(dom.Attrs("class", "placeholder")),
+		dom.A(dom.Attrs("href", "https://example.com", "target", "_self"), dom.InnerText("out")),
+		dom.A(dom.Attrs("href", "/in"), dom.InnerText("in")),
+		dom.Element("", nil, dom.Script(dom.Attrs())),
+	)
+
+	tests := []struct {
+		name    string
+		modify  func(dom.Node) domutil.Selection
+		want    template.HTML
+		wantLen int
+	}{
+		{
+			name: "AddClass",
+			modify: func(tree dom.Node) domutil.Selection {
+				return domutil.Select(tree, "td.numeric").AddClass("right", "mono")
+			},
+			want:    `<div><table><tr><td class="numeric right mono">1</td><td class="numeric right mono">2</td></tr></table><script>alert(1)</script><p class="placeholder"></p><a href="https://example.com" target="_self">out</a><a href="/in">in</a><script></script></div>`,
+			wantLen: 2,
+		},
+		{
+			name: "RemoveClass",
+			modify: func(tree dom.Node) domutil.Selection {
+				return domutil.Select(tree, "td").RemoveClass("numeric")
+			},
+			want:    `<div><table><tr><td class="">1</td><td class="right">2</td></tr></table><script>alert(1)</script><p class="placeholder"></p><a href="https://example.com" target="_self">out</a><a href="/in">in</a><script></script></div>`,
+			wantLen: 2,
+		},
+		{
+			name: "Select",
+			modify: func(tree dom.Node) domutil.Selection {
+				return domutil.Select(tree, "script")
+			},
+			want:    given.HTML(),
+			wantLen: 2,
+		},
+		{
+			name: "Remove",
+			modify: func(tree dom.Node) domutil.Selection {
+				return domutil.Select(tree, "script, table").Remove()
+			},
+			want:    `<div><p class="placeholder"></p><a href="https://example.com" target="_self">out</a><a href="/in">in</a></div>`,
+			wantLen: 0,
+		},
+		{
+			name: "SetAttr",
+			modify: func(tree dom.Node) domutil.Selection {
+				return domutil.Select(tree, "a[href^=http]").SetAttr("target", "_blank").SetAttr("rel", "noopener")
+			},
+			want:    `<div><table><tr><td class="numeric">1</td><td class="numeric right">2</td></tr></table><script>alert(1)</script><p class="placeholder"></p><a href="https://example.com" target="_blank" rel="noopener">out</a><a href="/in">in</a><script></script></div>`,
+			wantLen: 1,
+		},
+		{
+			name: "RemoveAttr",
+			modify: func(tree dom.Node) domutil.Selection {
+				return domutil.Select(tree, "a").RemoveAttr("TARGET")
+			},
+			want:    `<div><table><tr><td class="numeric">1</td><td class="numeric right">2</td></tr></table><script>alert(1)</script><p class="placeholder"></p><a href="https://example.com">out</a><a href="/in">in</a><script></script></div>`,
+			wantLen: 2,
+		},
+		{
+			name: "ReplaceWith",
+			modify: func(tree dom.Node) domutil.Selection {
+				return domutil.Select(tree, ".placeholder").ReplaceWith(dom.Strong(dom.Attrs(), dom.InnerText("generated"))).AddClass("new")
+			},
+			want:    `<div><table><tr><td class="numeric">1</td><td class="numeric right">2</td></tr></table><script>alert(1)</script><strong class="new">generated</strong><a href="https://example.com" target="_self">out</a><a href="/in">in</a><script></script></div>`,
+			wantLen: 1,
+		},
+		{
+			name: "Wrap",
+			modify: func(tree dom.Node) domutil.Selection {
+				return domutil.Select(tree, "table, td:first-child").Wrap(dom.Div(dom.Attrs("class", "scroll"), dom.InnerText("*"))).AddClass("wrapped")
+			},
+			want:    `<div><div class="scroll">*<table class="wrapped"><tr><div class="scroll">*<td class="numeric wrapped">1</td></div><td class="numeric right">2</td></tr></table></div><script>alert(1)</script><p class="placeholder"></p><a href="https://example.com" target="_self">out</a><a href="/in">in</a><script></script></div>`,
+			wantLen: 2,
+		},
+	}
+	nested := dom.Div(dom.Attrs(), dom.Div(dom.Attrs(), dom.Div(dom.Attrs(), dom.InnerText("x"))))
+	tests = append(tests, []struct {
+		name    string
+		modify  func(dom.Node) domutil.Selection
+		want    template.HTML
+		wantLen int
+	}{
+		{
+			name: "ReplaceWith nested",
+			modify: func(dom.Node) domutil.Selection {
+				return domutil.Select(nested, "div div").ReplaceWith(dom.Br(dom.Attrs()))
+			},
+			want:    `<div><br/></div>`,
+			wantLen: 1,
+		},
+		{
+			name: "AddClass nested",
+			modify: func(dom.Node) domutil.Selection {
+				return domutil.Select(nested, "div div").AddClass("a").AddClass("b")
+			},
+			want:    `<div><div class="a b"><div class="a b">x</div></div></div>`,
+			wantLen: 2,
+		},
+		{
+			name: "Map nested, keeping the inner replacement",
+			modify: func(dom.Node) domutil.Selection {
+				return domutil.Select(nested, "div div").Map(func(n dom.Node) dom.Node {
+					return dom.Section(dom.Attrs(), n.Children...)
+				})
+			},
+			want:    `<div><section><section>x</section></section></div>`,
+			wantLen: 2,
+		},
+	}...)
+	for _, tt := range tests {
+		oldHTML := given.HTML()
+		selection := tt.modify(given)
+		if err := selection.Err(); err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if got := selection.Tree().HTML(); got != tt.want {
+			t.Errorf("%s:\ngot      %q\nbut want %q", tt.name, got, tt.want)
+		}
+		if got := selection.Len(); got != tt.wantLen {
+			t.Errorf("%s: want %d selected but got %d", tt.name, tt.wantLen, got)
+		}
+		if got := len(selection.Nodes()); got != tt.wantLen {
+			t.Errorf("%s: want %d nodes but got %d", tt.name, tt.wantLen, got)
+		}
+		if oldHTML != given.HTML() {
+			t.Errorf("%s: modified the given node", tt.name)
+		}
+	}
+}
+
+func TestSelectionErr(t *testing.T) {
+	given := dom.P(dom.Attrs(), dom.InnerText("hello"))
+	selection := domutil.Select(given, "p:hover").AddClass("x")
+	if selection.Err() == nil {
+		t.Fatalf("want error")
+	}
+	if got, want := selection.Tree().HTML(), given.HTML(); got != want {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+}
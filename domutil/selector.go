@@ -0,0 +1,639 @@
+package domutil
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// QuerySelector returns the first element of tree, in the order they are rendered,
+// that matches the CSS selector. See Compile for the supported syntax.
+func QuerySelector(tree dom.Node, selector string) (dom.Node, bool, error) {
+	s, err := Compile(selector)
+	if err != nil {
+		return dom.Node{}, false, err
+	}
+	node, ok := s.Query(tree)
+	return node, ok, nil
+}
+
+// QuerySelectorAll returns every element of tree, in the order they are rendered,
+// that matches the CSS selector. See Compile for the supported syntax.
+func QuerySelectorAll(tree dom.Node, selector string) ([]dom.Node, error) {
+	s, err := Compile(selector)
+	if err != nil {
+		return nil, err
+	}
+	return s.QueryAll(tree), nil
+}
+
+// SelectorError describes a CSS selector that cannot be compiled.
+type SelectorError struct {
+	Selector string
+	Offset   int // byte offset into Selector where the problem is
+	Message  string
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("domutil: %s at offset %d of selector %q", e.Message, e.Offset, e.Selector)
+}
+
+// Selector is a compiled CSS selector.
+//
+// Only elements, i.e. Nodes with a Name, are matched. Nodes without a Name are
+// transparent like they are in the rendered HTML: the children of a `dom.Element("", ...)`
+// are children of its parent element. InnerHTML is not looked into.
+type Selector struct {
+	source string
+	groups []complexSelector
+}
+
+// Compile parses a CSS selector. The supported syntax is
+//
+//   - selector lists `a, b`
+//   - type `div`, universal `*`, class `.name` and id `#name` selectors
+//   - attribute selectors `[name]`, `[name=value]`, `[name~=value]`, `[name|=value]`,
+//     `[name^=value]`, `[name$=value]` and `[name*=value]`, with an optional `i` flag
+//     for case-insensitive values, e.g. `[type=email i]`
+//   - descendant `a b`, child `a > b`, next sibling `a + b` and subsequent sibling
+//     `a ~ b` combinators
+//   - the pseudo-classes `:not(...)`, `:nth-child(an+b)`, `:nth-last-child(an+b)`,
+//     `:first-child`, `:last-child`, `:only-child` and `:empty`
+//
+// Anything else is reported as a *SelectorError.
+func Compile(selector string) (*Selector, error) {
+	p := &selectorParser{source: selector}
+	groups, err := p.parseList(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{source: selector, groups: groups}, nil
+}
+
+// MustCompile is like Compile but panics if the selector cannot be compiled.
+func MustCompile(selector string) *Selector {
+	s, err := Compile(selector)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String returns the source of the selector.
+func (s *Selector) String() string {
+	return s.source
+}
+
+// Query returns the first matching element of tree, in the order they are rendered.
+func (s *Selector) Query(tree dom.Node) (dom.Node, bool) {
+	matches := s.match(tree)
+	if len(matches) == 0 {
+		return dom.Node{}, false
+	}
+	return matches[0].node, true
+}
+
+// QueryAll returns every matching element of tree, in the order they are rendered.
+func (s *Selector) QueryAll(tree dom.Node) []dom.Node {
+	var nodes []dom.Node
+	for _, el := range s.match(tree) {
+		nodes = append(nodes, el.node)
+	}
+	return nodes
+}
+
+// match returns the matching elements of tree, in the order they are rendered.
+func (s *Selector) match(tree dom.Node) []element {
+	elems := indexElements(tree)
+	var matches []element
+	for i := range elems {
+		for _, group := range s.groups {
+			if group.matches(elems, i) {
+				matches = append(matches, elems[i])
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// element is an element of a tree, with the relations that selectors look at.
+type element struct {
+	node     dom.Node
+	path     []int // see Cursor.Path
+	parent   int   // index of the parent element, or -1
+	prev     int   // index of the previous sibling element, or -1
+	position int   // 1-based position among its sibling elements
+	siblings int   // number of sibling elements, including itself
+	empty    bool  // no child elements and no text
+}
+
+// indexElements returns the elements of tree in the order they are rendered.
+func indexElements(tree dom.Node) []element {
+	var elems []element
+	counts := map[int]int{} // parent index to number of child elements
+
+	var visit func(n dom.Node, path []int, parent int, prev *int) bool
+	visit = func(n dom.Node, path []int, parent int, prev *int) bool {
+		if n.Name == "" {
+			if n.InnerText != "" || n.InnerHTML != "" {
+				return true
+			}
+			hasText := false
+			for i, child := range n.Children {
+				if visit(child, append(path, i), parent, prev) {
+					hasText = true
+				}
+			}
+			return hasText
+		}
+
+		index := len(elems)
+		counts[parent]++
+		elems = append(elems, element{
+			node:     n,
+			path:     append([]int{}, path...),
+			parent:   parent,
+			prev:     *prev,
+			position: counts[parent],
+		})
+		*prev = index
+
+		empty := n.InnerText == "" && n.InnerHTML == ""
+		childPrev := -1
+		for i, child := range n.Children {
+			if visit(child, append(path, i), index, &childPrev) {
+				empty = false
+			}
+		}
+		elems[index].empty = empty && counts[index] == 0
+		return false
+	}
+	prev := -1
+	visit(tree, []int{}, -1, &prev)
+
+	for i := range elems {
+		elems[i].siblings = counts[elems[i].parent]
+	}
+	return elems
+}
+
+// complexSelector is compound selectors joined by combinators, e.g. `ul > li.active a`.
+type complexSelector struct {
+	compounds   []compoundSelector
+	combinators []byte // combinators[i] is between compounds[i] and compounds[i+1]: ' ', '>', '+' or '~'
+}
+
+func (cs complexSelector) matches(elems []element, i int) bool {
+	return cs.matchesAt(elems, len(cs.compounds)-1, i)
+}
+
+// matchesAt reports whether elems[i] matches compounds[k], and the compounds before
+// it match the elements related to it by the combinators in between.
+func (cs complexSelector) matchesAt(elems []element, k int, i int) bool {
+	if !cs.compounds[k].matches(elems, i) {
+		return false
+	}
+	if k == 0 {
+		return true
+	}
+	switch cs.combinators[k-1] {
+	case '>':
+		return elems[i].parent >= 0 && cs.matchesAt(elems, k-1, elems[i].parent)
+	case '+':
+		return elems[i].prev >= 0 && cs.matchesAt(elems, k-1, elems[i].prev)
+	case '~':
+		for j := elems[i].prev; j >= 0; j = elems[j].prev {
+			if cs.matchesAt(elems, k-1, j) {
+				return true
+			}
+		}
+	default:
+		for j := elems[i].parent; j >= 0; j = elems[j].parent {
+			if cs.matchesAt(elems, k-1, j) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compoundSelector is simple selectors that all apply to one element, e.g. `li.active`.
+type compoundSelector struct {
+	tagName string // empty for any
+	filters []func(elems []element, i int) bool
+}
+
+func (c compoundSelector) matches(elems []element, i int) bool {
+	if c.tagName != "" && !strings.EqualFold(c.tagName, elems[i].node.Name) {
+		return false
+	}
+	for _, filter := range c.filters {
+		if !filter(elems, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// attrValue returns the value of attribute name, case-insensitively like HTML does.
+func attrValue(n dom.Node, name string) (string, bool) {
+	for _, attr := range n.Attributes {
+		if !strings.EqualFold(attr.Name, name) {
+			continue
+		}
+		if attr.ValueHTML != "" {
+			return html.UnescapeString(string(attr.ValueHTML)), true
+		}
+		return attr.ValueText, true
+	}
+	return "", false
+}
+
+// selectorParser is a recursive descent parser of CSS selectors.
+type selectorParser struct {
+	source string
+	pos    int
+}
+
+func (p *selectorParser) errorf(offset int, format string, args ...interface{}) error {
+	return &SelectorError{Selector: p.source, Offset: offset, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *selectorParser) eof() bool {
+	return p.pos >= len(p.source)
+}
+
+func (p *selectorParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.source[p.pos]
+}
+
+// skipSpace skips whitespace and reports whether there was any.
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\n\r\f", p.peek()) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// parseList parses comma separated complex selectors, up to a closing
+// parenthesis when nested inside a pseudo-class.
+func (p *selectorParser) parseList(nested bool) ([]complexSelector, error) {
+	var groups []complexSelector
+	for {
+		p.skipSpace()
+		cs, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, cs)
+		p.skipSpace()
+		switch {
+		case p.peek() == ',':
+			p.pos++
+		case nested && p.peek() == ')':
+			return groups, nil
+		case p.eof() && !nested:
+			return groups, nil
+		case p.eof():
+			return nil, p.errorf(p.pos, "missing closing parenthesis")
+		default:
+			return nil, p.errorf(p.pos, "unexpected %q", p.peek())
+		}
+	}
+}
+
+func (p *selectorParser) parseComplex() (complexSelector, error) {
+	var cs complexSelector
+	for {
+		compound, err := p.parseCompound()
+		if err != nil {
+			return cs, err
+		}
+		cs.compounds = append(cs.compounds, compound)
+
+		space := p.skipSpace()
+		combinator := p.peek()
+		switch {
+		case combinator == '>' || combinator == '+' || combinator == '~':
+			p.pos++
+			p.skipSpace()
+		case space && !p.eof() && combinator != ',' && combinator != ')':
+			combinator = ' '
+		default:
+			return cs, nil
+		}
+		cs.combinators = append(cs.combinators, combinator)
+	}
+}
+
+func (p *selectorParser) parseCompound() (compoundSelector, error) {
+	var c compoundSelector
+	start := p.pos
+	switch {
+	case p.peek() == '*':
+		p.pos++
+	case isIdentStart(p.peek()):
+		c.tagName = p.parseIdent()
+	}
+	for {
+		offset := p.pos
+		switch p.peek() {
+		case '#':
+			p.pos++
+			id := p.parseIdent()
+			if id == "" {
+				return c, p.errorf(offset, "missing id after #")
+			}
+			c.filters = append(c.filters, func(elems []element, i int) bool {
+				value, ok := attrValue(elems[i].node, "id")
+				return ok && value == id
+			})
+		case '.':
+			p.pos++
+			class := p.parseIdent()
+			if class == "" {
+				return c, p.errorf(offset, "missing class name after .")
+			}
+			c.filters = append(c.filters, func(elems []element, i int) bool {
+				value, _ := attrValue(elems[i].node, "class")
+				return containsWord(strings.Fields(value), class)
+			})
+		case '[':
+			p.pos++
+			filter, err := p.parseAttribute()
+			if err != nil {
+				return c, err
+			}
+			c.filters = append(c.filters, filter)
+		case ':':
+			p.pos++
+			filter, err := p.parsePseudo(offset)
+			if err != nil {
+				return c, err
+			}
+			c.filters = append(c.filters, filter)
+		default:
+			if p.pos == start {
+				if p.eof() {
+					return c, p.errorf(offset, "missing selector")
+				}
+				return c, p.errorf(offset, "unexpected %q", p.peek())
+			}
+			return c, nil
+		}
+	}
+}
+
+func (p *selectorParser) parseAttribute() (func(elems []element, i int) bool, error) {
+	p.skipSpace()
+	offset := p.pos
+	name := p.parseIdent()
+	if name == "" {
+		return nil, p.errorf(offset, "missing attribute name")
+	}
+	p.skipSpace()
+	if p.peek() == ']' {
+		p.pos++
+		return func(elems []element, i int) bool {
+			_, ok := attrValue(elems[i].node, name)
+			return ok
+		}, nil
+	}
+
+	offset = p.pos
+	var op string
+	if p.peek() == '=' {
+		op = "="
+	} else if p.pos+1 < len(p.source) && p.source[p.pos+1] == '=' && strings.IndexByte("~|^$*", p.peek()) >= 0 {
+		op = p.source[p.pos : p.pos+2]
+	} else if p.eof() {
+		return nil, p.errorf(offset, "missing ]")
+	} else {
+		return nil, p.errorf(offset, "unsupported attribute operator %q", p.peek())
+	}
+	p.pos += len(op)
+	p.skipSpace()
+
+	offset = p.pos
+	var want string
+	if q := p.peek(); q == '"' || q == '\'' {
+		value, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		want = value
+	} else if want = p.parseIdent(); want == "" {
+		return nil, p.errorf(offset, "missing attribute value")
+	}
+	p.skipSpace()
+
+	fold := false
+	if c := p.peek(); c == 'i' || c == 'I' || c == 's' || c == 'S' {
+		fold = c == 'i' || c == 'I'
+		p.pos++
+		p.skipSpace()
+	}
+	if p.peek() != ']' {
+		return nil, p.errorf(p.pos, "missing ]")
+	}
+	p.pos++
+
+	if fold {
+		want = strings.ToLower(want)
+	}
+	return func(elems []element, i int) bool {
+		value, ok := attrValue(elems[i].node, name)
+		if !ok {
+			return false
+		}
+		if fold {
+			value = strings.ToLower(value)
+		}
+		switch op {
+		case "~=":
+			return containsWord(strings.Fields(value), want)
+		case "|=":
+			return value == want || strings.HasPrefix(value, want+"-")
+		case "^=":
+			return want != "" && strings.HasPrefix(value, want)
+		case "$=":
+			return want != "" && strings.HasSuffix(value, want)
+		case "*=":
+			return want != "" && strings.Contains(value, want)
+		}
+		return value == want
+	}, nil
+}
+
+func (p *selectorParser) parsePseudo(offset int) (func(elems []element, i int) bool, error) {
+	name := strings.ToLower(p.parseIdent())
+	switch name {
+	case "first-child":
+		return func(elems []element, i int) bool { return elems[i].position == 1 }, nil
+	case "last-child":
+		return func(elems []element, i int) bool { return elems[i].position == elems[i].siblings }, nil
+	case "only-child":
+		return func(elems []element, i int) bool { return elems[i].siblings == 1 }, nil
+	case "empty":
+		return func(elems []element, i int) bool { return elems[i].empty }, nil
+	case "not", "nth-child", "nth-last-child":
+		// with arguments below
+	case "":
+		return nil, p.errorf(offset, "missing pseudo-class name")
+	default:
+		return nil, p.errorf(offset, "unsupported pseudo-class :%s", name)
+	}
+
+	if p.peek() != '(' {
+		return nil, p.errorf(p.pos, "missing ( after :%s", name)
+	}
+	p.pos++
+
+	if name == "not" {
+		groups, err := p.parseList(true)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++ // the closing parenthesis
+		return func(elems []element, i int) bool {
+			for _, group := range groups {
+				if group.matches(elems, i) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+
+	end := strings.IndexByte(p.source[p.pos:], ')')
+	if end < 0 {
+		return nil, p.errorf(p.pos, "missing closing parenthesis")
+	}
+	a, b, err := parseNth(p.source[p.pos : p.pos+end])
+	if err != nil {
+		return nil, p.errorf(p.pos, "%s", err.Error())
+	}
+	p.pos += end + 1
+
+	if name == "nth-last-child" {
+		return func(elems []element, i int) bool {
+			return nthMatches(a, b, elems[i].siblings-elems[i].position+1)
+		}, nil
+	}
+	return func(elems []element, i int) bool {
+		return nthMatches(a, b, elems[i].position)
+	}, nil
+}
+
+// parseIdent parses a CSS identifier, with backslash escapes, and returns "" if there is none.
+func (p *selectorParser) parseIdent() string {
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\\' && p.pos+1 < len(p.source):
+			sb.WriteByte(p.source[p.pos+1])
+			p.pos += 2
+		case isIdentStart(c) || (c >= '0' && c <= '9'):
+			sb.WriteByte(c)
+			p.pos++
+		default:
+			return sb.String()
+		}
+	}
+	return sb.String()
+}
+
+// parseString parses a single or double quoted string.
+func (p *selectorParser) parseString() (string, error) {
+	offset := p.pos
+	quote := p.peek()
+	p.pos++
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\' && p.pos+1 < len(p.source):
+			sb.WriteByte(p.source[p.pos+1])
+			p.pos += 2
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf(offset, "unterminated string")
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNth parses the `an+b` argument of :nth-child, including `odd` and `even`.
+func parseNth(s string) (a, b int, err error) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), ""))
+	switch s {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	case "":
+		return 0, 0, fmt.Errorf("missing an+b")
+	}
+
+	n := strings.IndexByte(s, 'n')
+	if n < 0 {
+		b, err = strconv.Atoi(s)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid an+b %q", s)
+		}
+		return 0, b, nil
+	}
+	switch coefficient := s[:n]; coefficient {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		if a, err = strconv.Atoi(coefficient); err != nil {
+			return 0, 0, fmt.Errorf("invalid an+b %q", s)
+		}
+	}
+	if rest := s[n+1:]; rest != "" {
+		if rest[0] != '+' && rest[0] != '-' {
+			return 0, 0, fmt.Errorf("invalid an+b %q", s)
+		}
+		if b, err = strconv.Atoi(rest); err != nil {
+			return 0, 0, fmt.Errorf("invalid an+b %q", s)
+		}
+	}
+	return a, b, nil
+}
+
+// nthMatches reports whether position is a*n+b for some n >= 0.
+func nthMatches(a, b, position int) bool {
+	if a == 0 {
+		return position == b
+	}
+	diff := position - b
+	return diff%a == 0 && diff/a >= 0
+}
@@ -0,0 +1,121 @@
+package domutil_test
+
+import (
+	"errors"
+	"html/template"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+var selectorTree = dom.Div(dom.Attrs("id", "page", "class", "container main"),
+	dom.Form(dom.Attrs("method", "post"),
+		dom.Input(dom.Attrs("name", "email", "type", "EMAIL")),
+		dom.Input(dom.Attrs("name", "password", "type", "password")),
+	),
+	dom.Table(dom.Attrs("class", "results"),
+		dom.Tbody(dom.Attrs(),
+			dom.Tr(dom.Attrs(), dom.Td(dom.Attrs("class", "numeric"), dom.InnerText("1")), dom.Td(dom.Attrs(), dom.InnerText("one"))),
+			// rows in a fragment are still children of tbody
+			dom.Element("", nil,
+				dom.Tr(dom.Attrs(), dom.Td(dom.Attrs("class", "numeric"), dom.InnerText("2")), dom.Td(dom.Attrs(), dom.InnerText("two"))),
+				dom.Tr(dom.Attrs(), dom.Td(dom.Attrs("class", "numeric"), dom.InnerText("3")), dom.Td(dom.Attrs(), dom.InnerText("three"))),
+			),
+		),
+	),
+	dom.P(dom.Attrs("lang", "en-US"),
+		dom.A(dom.Attrs("href", "https://example.com/a.pdf", "rel", "nofollow noopener"), dom.InnerText("external")),
+		dom.InnerText(" and "),
+		dom.A(dom.Attrs("href", "/local"), dom.InnerText("local")),
+		dom.Span(dom.Attrs("data-x", `a "quoted" value`)),
+		dom.Em(dom.Attrs()),
+	),
+)
+
+func TestQuerySelectorAll(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     template.HTML // the matches joined
+	}{
+		{selector: "input[name=email]", want: `<input name="email" type="EMAIL"/>`},
+		{selector: `input[type="email" i]`, want: `<input name="email" type="EMAIL"/>`},
+		{selector: "input[type=email]", want: ``},
+		{selector: "a[href^=http]", want: `<a href="https://example.com/a.pdf" rel="nofollow noopener">external</a>`},
+		{selector: "a[href$='.pdf']", want: `<a href="https://example.com/a.pdf" rel="nofollow noopener">external</a>`},
+		{selector: "a[href*=loc]", want: `<a href="/local">local</a>`},
+		{selector: "a[rel~=noopener]", want: `<a href="https://example.com/a.pdf" rel="nofollow noopener">external</a>`},
+		{selector: "[lang|=en]", want: selectorTree.Children[2].HTML()},
+		{selector: `[data-x='a "quoted" value']`, want: `<span data-x="a &#34;quoted&#34; value"></span>`},
+		{selector: "#page", want: selectorTree.HTML()},
+		{selector: "DIV.main.container", want: selectorTree.HTML()},
+		{selector: "table.results > tbody > tr:nth-child(2) td", want: `<td class="numeric">2</td><td>two</td>`},
+		{selector: "tr:nth-child(odd) > td:first-child", want: `<td class="numeric">1</td><td class="numeric">3</td>`},
+		{selector: "tr:nth-last-child(1) td:last-child", want: `<td>three</td>`},
+		{selector: "tr:nth-child(-n + 2) td.numeric", want: `<td class="numeric">1</td><td class="numeric">2</td>`},
+		{selector: "td:not(.numeric)", want: `<td>one</td><td>two</td><td>three</td>`},
+		{selector: "td:not(.numeric, :nth-child(2))", want: ``},
+		{selector: "input + input", want: `<input name="password" type="password"/>`},
+		{selector: "form ~ p > a:first-child", want: `<a href="https://example.com/a.pdf" rel="nofollow noopener">external</a>`},
+		{selector: "a ~ span", want: `<span data-x="a &#34;quoted&#34; value"></span>`},
+		{selector: "p > :empty", want: `<span data-x="a &#34;quoted&#34; value"></span><em></em>`},
+		{selector: "tbody > :only-child", want: ``},
+		{selector: "form > *:last-child, em", want: `<input name="password" type="password"/><em></em>`},
+	}
+	for _, tt := range tests {
+		nodes, err := domutil.QuerySelectorAll(selectorTree, tt.selector)
+		if err != nil {
+			t.Errorf("%s: %s", tt.selector, err)
+			continue
+		}
+		if got := domutil.Join(nodes...).HTML(); got != tt.want {
+			t.Errorf("%s:\ngot      %q\nbut want %q", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestQuerySelector(t *testing.T) {
+	got, ok, err := domutil.QuerySelector(selectorTree, "td.numeric")
+	if err != nil || !ok {
+		t.Fatalf("want a match but got %v %v", ok, err)
+	}
+	if want := template.HTML(`<td class="numeric">1</td>`); got.HTML() != want {
+		t.Errorf("\ngot      %q\nbut want %q", got.HTML(), want)
+	}
+
+	if _, ok, err := domutil.QuerySelector(selectorTree, "video"); ok || err != nil {
+		t.Errorf("want no match but got %v %v", ok, err)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	tests := []struct {
+		selector string
+		offset   int
+		message  string
+	}{
+		{selector: "", offset: 0, message: "missing selector"},
+		{selector: "a,", offset: 2, message: "missing selector"},
+		{selector: "a >", offset: 3, message: "missing selector"},
+		{selector: "a:hover", offset: 1, message: "unsupported pseudo-class :hover"},
+		{selector: "a::before", offset: 1, message: "missing pseudo-class name"},
+		{selector: "a[href", offset: 6, message: "missing ]"},
+		{selector: "a[href!=x]", offset: 6, message: `unsupported attribute operator '!'`},
+		{selector: "a[href='x]", offset: 7, message: "unterminated string"},
+		{selector: "li:nth-child(2n+)", offset: 13, message: `invalid an+b "2n+"`},
+		{selector: "li:not(a", offset: 8, message: "missing closing parenthesis"},
+		{selector: "a { color: red }", offset: 2, message: `unexpected '{'`},
+		{selector: "#", offset: 0, message: "missing id after #"},
+	}
+	for _, tt := range tests {
+		_, err := domutil.Compile(tt.selector)
+		var selectorErr *domutil.SelectorError
+		if !errors.As(err, &selectorErr) {
+			t.Errorf("%s: want *SelectorError but got %#v", tt.selector, err)
+			continue
+		}
+		if selectorErr.Offset != tt.offset || selectorErr.Message != tt.message {
+			t.Errorf("%s: want %d %q but got %d %q", tt.selector, tt.offset, tt.message, selectorErr.Offset, selectorErr.Message)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package domutil
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// TOCOptions configures TOC. The zero value is ready to use.
+type TOCOptions struct {
+	// MinDepth and MaxDepth are the levels of the headings to include, e.g. 2 and 3
+	// for `<h2>` and `<h3>`. Default to 1 and 6.
+	MinDepth int
+	MaxDepth int
+
+	// Ordered uses `<ol>` instead of `<ul>` for the table of contents.
+	Ordered bool
+}
+
+// TOC returns tree with an `id` on every heading, and a table of contents of nested
+// lists linking to them. e.g.
+//
+//	<ul>
+//	    <li><a href="#install">Install</a>
+//	        <ul><li><a href="#from-source">From source</a></li></ul>
+//	    </li>
+//	    <li><a href="#usage">Usage</a></li>
+//	</ul>
+//
+// Headings that already have an `id` keep it. Others are given one from their text,
+// e.g. "From source" is "from-source", with a number added to tell apart any that
+// would be the same, e.g. "usage-1". The table of contents is empty if there are
+// no headings.
+//
+// InnerHTML is not parsed: headings in it are left out of the table of contents,
+// and its ids are not seen, so an id given to a heading may also be in InnerHTML.
+//
+// Like ReplaceAll, the given tree is not modified.
+func TOC(tree dom.Node, opts TOCOptions) (dom.Node, dom.Node) {
+	if opts.MinDepth <= 0 {
+		opts.MinDepth = 1
+	}
+	if opts.MaxDepth <= 0 || opts.MaxDepth > 6 {
+		opts.MaxDepth = 6
+	}
+
+	used := map[string]bool{}
+	Walk(tree, Visitor{
+		Enter: func(c Cursor) WalkAction {
+			if id, ok := attrValue(c.Node, "id"); ok {
+				used[id] = true
+			}
+			return Continue
+		},
+	})
+
+	root := &tocEntry{}
+	stack := []*tocEntry{root}
+	newTree := Transform(tree, func(c Cursor) dom.Node {
+		level := headingLevel(c.Node.Name)
+		if level < opts.MinDepth || level > opts.MaxDepth {
+			return c.Node
+		}
+
+		text := strings.Join(strings.Fields(textContent(c.Node)), " ")
+		id, ok := attrValue(c.Node, "id")
+		if !ok {
+			id = uniqueSlug(slugify(text), used)
+			c.Node = c.Node.SetAttr("id", id)
+		}
+
+		entry := &tocEntry{level: level, id: id, text: text}
+		for len(stack) > 1 && stack[len(stack)-1].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, entry)
+		stack = append(stack, entry)
+		return c.Node
+	})
+
+	if len(root.children) == 0 {
+		return newTree, dom.Node{}
+	}
+	return newTree, root.list(opts.Ordered)
+}
+
+// tocEntry is a heading in the table of contents, and the headings under it.
+type tocEntry struct {
+	level    int
+	id       string
+	text     string
+	children []*tocEntry
+}
+
+func (e *tocEntry) list(ordered bool) dom.Node {
+	items := make([]dom.Node, 0, len(e.children))
+	for _, child := range e.children {
+		item := []dom.Node{dom.A(dom.Attrs("href", "#"+child.id), dom.InnerText(child.text))}
+		if len(child.children) > 0 {
+			item = append(item, child.list(ordered))
+		}
+		items = append(items, dom.Li(dom.Attrs(), item...))
+	}
+	if ordered {
+		return dom.Ol(dom.Attrs(), items...)
+	}
+	return dom.Ul(dom.Attrs(), items...)
+}
+
+// headingLevel returns the level of a `<h1>` to `<h6>`, or 0 for other elements.
+func headingLevel(name string) int {
+	if len(name) == 2 && (name[0] == 'h' || name[0] == 'H') && name[1] >= '1' && name[1] <= '6' {
+		return int(name[1] - '0')
+	}
+	return 0
+}
+
+// slugify returns text as lower case letters and digits separated by dashes, without
+// diacritics, e.g. "Café au lait!" is "cafe-au-lait".
+func slugify(text string) string {
+	folded, _ := foldText(text)
+	var sb strings.Builder
+	dash := false
+	for _, r := range folded {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if sb.Len() == 0 {
+		return "section"
+	}
+	return sb.String()
+}
+
+// uniqueSlug returns slug, or slug with a number added, that is not used yet, and
+// marks it used.
+func uniqueSlug(slug string, used map[string]bool) string {
+	unique := slug
+	for i := 1; used[unique]; i++ {
+		unique = slug + "-" + strconv.Itoa(i)
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,100 @@
+package domutil_test
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+func TestTOC(t *testing.T) {
+	page := dom.Article(dom.Attrs(),
+		dom.H1(dom.Attrs(), dom.InnerText("Guide")),
+		dom.H2(dom.Attrs(), dom.InnerText("Install")),
+		dom.H3(dom.Attrs(), dom.InnerHTML("From <em>source</em> &amp; binaries")),
+		dom.H2(dom.Attrs("id", "usage"), dom.InnerText("Usage")),
+		dom.H2(dom.Attrs(), dom.InnerText("Usage")),
+		dom.H4(dom.Attrs(), dom.InnerText("Café au lait!")),
+		dom.H2(dom.Attrs(), dom.InnerText("???")),
+	)
+
+	tests := []struct {
+		name     string
+		given    dom.Node
+		opts     domutil.TOCOptions
+		wantTree template.HTML
+		wantTOC  template.HTML
+	}{
+		{
+			name:  "all headings",
+			given: page,
+			wantTree: `<article>` +
+				`<h1 id="guide">Guide</h1>` +
+				`<h2 id="install">Install</h2>` +
+				`<h3 id="from-source-binaries">From <em>source</em> &amp; binaries</h3>` +
+				`<h2 id="usage">Usage</h2>` +
+				`<h2 id="usage-1">Usage</h2>` +
+				`<h4 id="cafe-au-lait">Café au lait!</h4>` +
+				`<h2 id="section">???</h2>` +
+				`</article>`,
+			wantTOC: `<ul><li><a href="#guide">Guide</a><ul>` +
+				`<li><a href="#install">Install</a><ul><li><a href="#from-source-binaries">From source &amp; binaries</a></li></ul></li>` +
+				`<li><a href="#usage">Usage</a></li>` +
+				`<li><a href="#usage-1">Usage</a><ul><li><a href="#cafe-au-lait">Café au lait!</a></li></ul></li>` +
+				`<li><a href="#section">???</a></li>` +
+				`</ul></li></ul>`,
+		},
+		{
+			name:  "depth and ordered",
+			given: page,
+			opts:  domutil.TOCOptions{MinDepth: 2, MaxDepth: 2, Ordered: true},
+			wantTree: `<article>` +
+				`<h1>Guide</h1>` +
+				`<h2 id="install">Install</h2>` +
+				`<h3>From <em>source</em> &amp; binaries</h3>` +
+				`<h2 id="usage">Usage</h2>` +
+				`<h2 id="usage-1">Usage</h2>` +
+				`<h4>Café au lait!</h4>` +
+				`<h2 id="section">???</h2>` +
+				`</article>`,
+			wantTOC: `<ol>` +
+				`<li><a href="#install">Install</a></li>` +
+				`<li><a href="#usage">Usage</a></li>` +
+				`<li><a href="#usage-1">Usage</a></li>` +
+				`<li><a href="#section">???</a></li>` +
+				`</ol>`,
+		},
+		{
+			name: "slugs do not collide with other ids",
+			given: dom.Div(dom.Attrs(),
+				dom.Section(dom.Attrs("id", "intro")),
+				dom.H2(dom.Attrs(), dom.InnerText("Intro")),
+			),
+			wantTree: `<div><section id="intro"></section><h2 id="intro-1">Intro</h2></div>`,
+			wantTOC:  `<ul><li><a href="#intro-1">Intro</a></li></ul>`,
+		},
+		{
+			name:     "no headings",
+			given:    dom.P(dom.Attrs(), dom.InnerText("Hello")),
+			wantTree: `<p>Hello</p>`,
+			wantTOC:  ``,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.given.HTML()
+			tree, toc := domutil.TOC(tt.given, tt.opts)
+			if got := tree.HTML(); got != tt.wantTree {
+				t.Errorf("tree\ngot      %q\nbut want %q", got, tt.wantTree)
+			}
+			if got := toc.HTML(); got != tt.wantTOC {
+				t.Errorf("toc\ngot      %q\nbut want %q", got, tt.wantTOC)
+			}
+			if got := tt.given.HTML(); got != before {
+				t.Errorf("given node was modified\ngot      %q\nbut want %q", got, before)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package domutil
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/internal/htmltoken"
+)
+
+// TruncateOptions configures Truncate. The zero value is ready to use.
+type TruncateOptions struct {
+	// Words counts the limit in words instead of characters.
+	Words bool
+
+	// Ellipsis is added right after the truncated text. Defaults to "…", unless
+	// NoEllipsis is set.
+	Ellipsis   dom.Node
+	NoEllipsis bool
+}
+
+// Truncate cuts the text of tree down to limit visible characters, or words, and
+// reports whether anything was cut. e.g. for a teaser of an article.
+//
+// Markup is not counted, and runs of whitespace count as one character. The text is
+// cut between words, after which the remaining nodes are dropped; the elements
+// still open are closed as usual, including those inside InnerHTML. The contents
+// of `<script>` and `<style>` are not counted.
+//
+// Like ReplaceAll, the given tree is not modified.
+func Truncate(tree dom.Node, limit int, opts TruncateOptions) (dom.Node, bool) {
+	if !opts.NoEllipsis && isZeroNode(opts.Ellipsis) {
+		opts.Ellipsis = dom.InnerText("…")
+	}
+	t := &truncater{limit: limit, afterSpace: true, words: opts.Words}
+	at, cut := t.measure(tree)
+	if !cut {
+		return tree, false
+	}
+	c := &cutter{at: at, opts: opts}
+	return c.cut(tree)
+}
+
+// textPosition is a position in the text of a tree: the byte offset in the text of
+// the segment-th InnerText, or text of an InnerHTML, in the order they are rendered.
+// Offsets into InnerHTML are into its unescaped text.
+type textPosition struct {
+	segment int
+	offset  int
+}
+
+// truncater keeps count of the text seen so far, to find where to cut it.
+type truncater struct {
+	limit int
+	words bool
+
+	count        int          // characters or words seen so far
+	afterSpace   bool         // the text seen so far ends with whitespace, or there was none
+	pendingSpace bool         // whitespace seen after the last counted character
+	segment      int          // texts seen so far
+	word         textPosition // where the last word seen starts, maybe in an earlier text
+}
+
+// measure returns where to cut the text of n if the limit is reached within it,
+// and whether it is.
+func (t *truncater) measure(n dom.Node) (textPosition, bool) {
+	switch {
+	case n.InnerHTML != "":
+		return t.measureHTML(string(n.InnerHTML))
+	case n.InnerText != "":
+		return t.consume(n.InnerText)
+	case isRawTextElement(n.Name):
+		return textPosition{}, false
+	}
+
+	if !isInlineElement(n.Name) {
+		t.boundary()
+		defer t.boundary()
+	}
+	for _, child := range n.Children {
+		if at, cut := t.measure(child); cut {
+			return at, true
+		}
+	}
+	return textPosition{}, false
+}
+
+// boundary is where a block starts or ends, which separates words like whitespace does.
+func (t *truncater) boundary() {
+	if !t.afterSpace {
+		t.pendingSpace = true
+	}
+	t.afterSpace = true
+}
+
+// consume counts the next text, and returns where to cut if the limit is reached.
+// That is before the word reaching the limit, even if the word started in an
+// earlier text.
+func (t *truncater) consume(text string) (textPosition, bool) {
+	segment := t.segment
+	t.segment++
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if !t.afterSpace {
+				t.pendingSpace = true
+			}
+			t.afterSpace = true
+			continue
+		}
+
+		here := textPosition{segment: segment, offset: i}
+		if t.words {
+			if t.afterSpace {
+				if t.count == t.limit {
+					return here, true
+				}
+				t.count++
+			}
+		} else {
+			cost := 1
+			if t.pendingSpace {
+				cost++
+			}
+			if t.count+cost > t.limit {
+				if t.afterSpace || unicode.IsPunct(r) {
+					return here, true
+				}
+				return t.word, true
+			}
+			t.count += cost
+		}
+		if t.afterSpace {
+			t.word = here
+		}
+		t.afterSpace, t.pendingSpace = false, false
+	}
+	return textPosition{}, false
+}
+
+// measureHTML is measure for InnerHTML.
+func (t *truncater) measureHTML(s string) (textPosition, bool) {
+	for _, token := range htmltoken.Tokenize(s) {
+		switch token.Kind {
+		case htmltoken.StartTag, htmltoken.EndTag:
+			if !isInlineElement(token.Name) {
+				t.boundary()
+			}
+		case htmltoken.Text:
+			text, _ := unescapeText(s[token.Start:token.End])
+			if at, cut := t.consume(text); cut {
+				return at, true
+			}
+		}
+	}
+	return textPosition{}, false
+}
+
+// cutter cuts a tree at the position found by a truncater.
+type cutter struct {
+	at   textPosition
+	opts TruncateOptions
+
+	segment int // texts seen so far
+}
+
+// cut returns n, cut if the position is within it, and whether it is.
+func (c *cutter) cut(n dom.Node) (dom.Node, bool) {
+	switch {
+	case n.InnerHTML != "":
+		s, cut := c.cutHTML(string(n.InnerHTML))
+		if !cut {
+			return n, false
+		}
+		return c.withEllipsis(n, dom.InnerHTML(s)), true
+	case n.InnerText != "":
+		if !c.next() {
+			return n, false
+		}
+		return c.withEllipsis(n, dom.InnerText(trimCut(n.InnerText[:c.at.offset]))), true
+	case isRawTextElement(n.Name):
+		return n, false
+	}
+
+	for i, child := range n.Children {
+		newChild, cut := c.cut(child)
+		if cut {
+			children := make([]dom.Node, 0, i+1)
+			children = append(children, n.Children[:i]...)
+			n.Children = append(children, newChild)
+			return n, true
+		}
+	}
+	return n, false
+}
+
+// next counts the next text, and reports whether it is the one to cut.
+func (c *cutter) next() bool {
+	c.segment++
+	return c.segment-1 == c.at.segment
+}
+
+// withEllipsis returns n with content, followed by the ellipsis, as its content.
+func (c *cutter) withEllipsis(n dom.Node, content dom.Node) dom.Node {
+	children := []dom.Node{content}
+	if !c.opts.NoEllipsis {
+		children = append(children, c.opts.Ellipsis)
+	}
+	if n.Name == "" {
+		return Join(children...)
+	}
+	n.InnerHTML, n.InnerText, n.Children = "", "", children
+	return n
+}
+
+// trimCut trims what is left dangling at the end of truncated text.
+func trimCut(s string) string {
+	return strings.TrimRightFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",;:-–—", r)
+	})
+}
+
+// cutHTML is cut for InnerHTML; it returns s cut, with the elements still open
+// closed, if the position is within it.
+func (c *cutter) cutHTML(s string) (string, bool) {
+	var open []string
+	for _, token := range htmltoken.Tokenize(s) {
+		switch token.Kind {
+		case htmltoken.StartTag:
+			if !token.SelfClose && !voidElements[token.Name] {
+				open = append(open, token.Name)
+			}
+		case htmltoken.EndTag:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == token.Name {
+					open = open[:i]
+					break
+				}
+			}
+		case htmltoken.Text:
+			if !c.next() {
+				continue
+			}
+			// trim the text rather than the html, e.g. not the `;` of `&amp;`
+			text, offsets := unescapeText(s[token.Start:token.End])
+			var sb strings.Builder
+			sb.WriteString(s[:token.Start+offsets[len(trimCut(text[:c.at.offset]))]])
+			for j := len(open) - 1; j >= 0; j-- {
+				sb.WriteString("</" + open[j] + ">")
+			}
+			return sb.String(), true
+		}
+	}
+	return s, false
+}
+
+// voidElements cannot have children, so are never closed.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "command": true, "embed": true,
+	"hr": true, "img": true, "input": true, "keygen": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// isInlineElement reports whether the element is part of the text around it, rather
+// than a block separating it. Nodes without a Name are inline.
+func isInlineElement(name string) bool {
+	name = strings.ToLower(name)
+	return name == "" || name == "a" || inlineFormatting[name]
+}
+
+// isRawTextElement reports whether the content of the element is not text to read.
+func isRawTextElement(name string) bool {
+	switch strings.ToLower(name) {
+	case "script", "style", "template":
+		return true
+	}
+	return false
+}
+
+func isZeroNode(n dom.Node) bool {
+	return n.Name == "" && n.InnerHTML == "" && n.InnerText == "" && len(n.Children) == 0 && len(n.Attributes) == 0
+}
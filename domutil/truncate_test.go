@@ -0,0 +1,130 @@
+package domutil_test
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+func TestTruncate(t *testing.T) {
+	article := dom.Article(dom.Attrs(),
+		dom.H2(dom.Attrs(), dom.InnerText("Hello world")),
+		dom.P(dom.Attrs(),
+			dom.InnerText("The   quick "),
+			dom.Strong(dom.Attrs(), dom.InnerText("brown")),
+			dom.InnerText(" fox, jumps"),
+		),
+		dom.Script(dom.Attrs(), dom.InnerText("var counted = false;")),
+		dom.P(dom.Attrs(), dom.InnerText("over the lazy dog.")),
+	)
+
+	tests := []struct {
+		name      string
+		given     dom.Node
+		limit     int
+		opts      domutil.TruncateOptions
+		want      template.HTML
+		truncated bool
+	}{
+		{
+			name:  "fits",
+			given: article,
+			limit: 100,
+			want:  article.HTML(),
+		},
+		{
+			name:      "not inside a word",
+			given:     article,
+			limit:     14, // "Hello world The", with a space between the blocks, is 15 characters
+			want:      `<article><h2>Hello world</h2><p>…</p></article>`,
+			truncated: true,
+		},
+		{
+			name:      "whitespace runs count once, and inline elements are kept balanced",
+			given:     article,
+			limit:     28, // "Hello world The quick brown f"
+			want:      `<article><h2>Hello world</h2><p>The   quick <strong>brown</strong>…</p></article>`,
+			truncated: true,
+		},
+		{
+			name:      "trailing punctuation is trimmed",
+			given:     article,
+			limit:     31,
+			want:      `<article><h2>Hello world</h2><p>The   quick <strong>brown</strong> fox…</p></article>`,
+			truncated: true,
+		},
+		{
+			name:      "inside an element",
+			given:     article,
+			limit:     22,
+			want:      `<article><h2>Hello world</h2><p>The   quick <strong>…</strong></p></article>`,
+			truncated: true,
+		},
+		{
+			name:      "script is not counted",
+			given:     article,
+			limit:     39,
+			want:      `<article><h2>Hello world</h2><p>The   quick <strong>brown</strong> fox, jumps</p><script>var counted = false;</script><p>…</p></article>`,
+			truncated: true,
+		},
+		{
+			name:      "words",
+			given:     article,
+			limit:     5,
+			opts:      domutil.TruncateOptions{Words: true, Ellipsis: dom.A(dom.Attrs("href", "/more"), dom.InnerText("more"))},
+			want:      `<article><h2>Hello world</h2><p>The   quick <strong>brown</strong><a href="/more">more</a></p></article>`,
+			truncated: true,
+		},
+		{
+			name:      "words across nodes",
+			given:     dom.P(dom.Attrs(), dom.InnerText("one tw"), dom.B(dom.Attrs(), dom.InnerText("o")), dom.InnerText(" three")),
+			limit:     2,
+			opts:      domutil.TruncateOptions{Words: true, NoEllipsis: true},
+			want:      `<p>one tw<b>o</b></p>`,
+			truncated: true,
+		},
+		{
+			name:      "InnerHTML is parsed and its open tags closed",
+			given:     dom.Div(dom.Attrs(), dom.InnerHTML(`<p class="a>b">Tom &amp; <em>Jerry <br>are <i>cat</i> and mouse</em></p><p>more</p>`)),
+			limit:     20, // "Tom & Jerry are cat and"
+			want:      `<div><p class="a>b">Tom &amp; <em>Jerry <br>are <i>cat</i></em></p>…</div>`,
+			truncated: true,
+		},
+		{
+			name:      "a word across elements is not cut inside",
+			given:     dom.P(dom.Attrs(), dom.InnerText("say hel"), dom.B(dom.Attrs(), dom.InnerText("lo world"))),
+			limit:     7,
+			want:      `<p>say…</p>`,
+			truncated: true,
+		},
+		{
+			name:      "a word across InnerHTML elements is not cut inside",
+			given:     dom.P(dom.Attrs(), dom.InnerHTML(`say hel<b>lo world</b>`)),
+			limit:     7,
+			want:      `<p>say…</p>`,
+			truncated: true,
+		},
+		{
+			name:      "a word across InnerHTML nodes is not cut inside",
+			given:     dom.P(dom.Attrs(), dom.InnerHTML(`one &amp; tw`), dom.I(dom.Attrs(), dom.InnerHTML(`o<b>three</b>`))),
+			limit:     8,
+			want:      `<p>one &amp;…</p>`,
+			truncated: true,
+		},
+	}
+	for _, tt := range tests {
+		oldHTML := tt.given.HTML()
+		got, truncated := domutil.Truncate(tt.given, tt.limit, tt.opts)
+		if got.HTML() != tt.want {
+			t.Errorf("%s:\ngot      %q\nbut want %q", tt.name, got.HTML(), tt.want)
+		}
+		if truncated != tt.truncated {
+			t.Errorf("%s: want truncated %v but got %v", tt.name, tt.truncated, truncated)
+		}
+		if oldHTML != tt.given.HTML() {
+			t.Errorf("%s: Truncate modified the given node", tt.name)
+		}
+	}
+}
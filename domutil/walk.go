@@ -0,0 +1,102 @@
+package domutil
+
+import "github.com/choonkeat/dom-go"
+
+// Cursor is the position of a node visited by Walk or Transform.
+//
+// Parents and Path are reused as the walk goes on; copy them to keep them
+// beyond the callback.
+type Cursor struct {
+	Node dom.Node
+
+	// Parents are the ancestors of Node, from the root down to its parent.
+	Parents []dom.Node
+
+	// Path is the child indices from the root to Node, e.g. `[]int{2, 0}` is the
+	// first child of the third child of the root. The root has an empty Path.
+	Path []int
+}
+
+// Parent returns the parent of the node, and false for the root.
+func (c Cursor) Parent() (dom.Node, bool) {
+	if len(c.Parents) == 0 {
+		return dom.Node{}, false
+	}
+	return c.Parents[len(c.Parents)-1], true
+}
+
+// WalkAction tells Walk how to proceed after a Visitor callback.
+type WalkAction int
+
+const (
+	// Continue walking as usual.
+	Continue WalkAction = iota
+
+	// SkipChildren does not visit the children of the node; when returned by
+	// Visitor.Exit it is the same as Continue.
+	SkipChildren
+
+	// Stop the walk, without calling Exit for the nodes still being visited.
+	Stop
+)
+
+// Visitor holds the callbacks of Walk; either can be nil.
+type Visitor struct {
+	// Enter is called before the children of the node are visited.
+	Enter func(Cursor) WalkAction
+
+	// Exit is called after the children of the node are visited.
+	Exit func(Cursor) WalkAction
+}
+
+// Walk visits every node of tree depth first, in the order they are rendered.
+func Walk(tree dom.Node, visitor Visitor) {
+	walk(Cursor{Node: tree, Path: []int{}}, visitor)
+}
+
+func walk(c Cursor, visitor Visitor) WalkAction {
+	if visitor.Enter != nil {
+		switch visitor.Enter(c) {
+		case Stop:
+			return Stop
+		case SkipChildren:
+			return exit(c, visitor)
+		}
+	}
+	parents := append(c.Parents, c.Node)
+	for i, child := range c.Node.Children {
+		if walk(Cursor{Node: child, Parents: parents, Path: append(c.Path, i)}, visitor) == Stop {
+			return Stop
+		}
+	}
+	return exit(c, visitor)
+}
+
+func exit(c Cursor, visitor Visitor) WalkAction {
+	if visitor.Exit != nil && visitor.Exit(c) == Stop {
+		return Stop
+	}
+	return Continue
+}
+
+// Transform returns a new tree where every node is replaced by fn(cursor), visiting
+// the children of a node before the node itself. i.e. the Cursor.Node given to fn
+// already has its transformed children, while Cursor.Parents are from the original
+// tree.
+//
+// Like ReplaceAll, the given tree is not modified.
+func Transform(tree dom.Node, fn func(Cursor) dom.Node) dom.Node {
+	return transform(Cursor{Node: tree, Path: []int{}}, fn)
+}
+
+func transform(c Cursor, fn func(Cursor) dom.Node) dom.Node {
+	if len(c.Node.Children) > 0 {
+		parents := append(c.Parents, c.Node)
+		newChildren := make([]dom.Node, 0, len(c.Node.Children))
+		for i, child := range c.Node.Children {
+			newChildren = append(newChildren, transform(Cursor{Node: child, Parents: parents, Path: append(c.Path, i)}, fn))
+		}
+		c.Node.Children = newChildren
+	}
+	return fn(c)
+}
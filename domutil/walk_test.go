@@ -0,0 +1,184 @@
+package domutil_test
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+// <div><p>one<b>two</b></p><ul><li>three</li></ul>four</div>
+var walkTree = dom.Div(dom.Attrs(),
+	dom.P(dom.Attrs(),
+		dom.InnerText("one"),
+		dom.B(dom.Attrs(), dom.InnerText("two")),
+	),
+	dom.Ul(dom.Attrs(), dom.Li(dom.Attrs(), dom.InnerText("three"))),
+	dom.InnerText("four"),
+)
+
+// describe returns e.g. "enter b [0 1] div>p"
+func describe(event string, c domutil.Cursor) string {
+	var names []string
+	for _, parent := range c.Parents {
+		names = append(names, parent.Name)
+	}
+	name := c.Node.Name
+	if name == "" {
+		name = fmt.Sprintf("%q", c.Node.InnerText)
+	}
+	return fmt.Sprintf("%s %s %v %s", event, name, c.Path, strings.Join(names, ">"))
+}
+
+func TestWalk(t *testing.T) {
+	tests := []struct {
+		name  string
+		enter func(domutil.Cursor) domutil.WalkAction
+		exit  func(domutil.Cursor) domutil.WalkAction
+		want  []string
+	}{
+		{
+			name: "visit all",
+			want: []string{
+				"enter div [] ",
+				"enter p [0] div",
+				`enter "one" [0 0] div>p`,
+				`exit "one" [0 0] div>p`,
+				"enter b [0 1] div>p",
+				`enter "two" [0 1 0] div>p>b`,
+				`exit "two" [0 1 0] div>p>b`,
+				"exit b [0 1] div>p",
+				"exit p [0] div",
+				"enter ul [1] div",
+				"enter li [1 0] div>ul",
+				`enter "three" [1 0 0] div>ul>li`,
+				`exit "three" [1 0 0] div>ul>li`,
+				"exit li [1 0] div>ul",
+				"exit ul [1] div",
+				`enter "four" [2] div`,
+				`exit "four" [2] div`,
+				"exit div [] ",
+			},
+		},
+		{
+			name: "skip children",
+			enter: func(c domutil.Cursor) domutil.WalkAction {
+				if c.Node.Name == "p" || c.Node.Name == "li" {
+					return domutil.SkipChildren
+				}
+				return domutil.Continue
+			},
+			want: []string{
+				"enter div [] ",
+				"enter p [0] div",
+				"exit p [0] div",
+				"enter ul [1] div",
+				"enter li [1 0] div>ul",
+				"exit li [1 0] div>ul",
+				"exit ul [1] div",
+				`enter "four" [2] div`,
+				`exit "four" [2] div`,
+				"exit div [] ",
+			},
+		},
+		{
+			name: "stop on enter",
+			enter: func(c domutil.Cursor) domutil.WalkAction {
+				if c.Node.Name == "b" {
+					return domutil.Stop
+				}
+				return domutil.Continue
+			},
+			want: []string{
+				"enter div [] ",
+				"enter p [0] div",
+				`enter "one" [0 0] div>p`,
+				`exit "one" [0 0] div>p`,
+				"enter b [0 1] div>p",
+			},
+		},
+		{
+			name: "stop on exit",
+			exit: func(c domutil.Cursor) domutil.WalkAction {
+				if c.Node.Name == "p" {
+					return domutil.Stop
+				}
+				return domutil.Continue
+			},
+			want: []string{
+				"enter div [] ",
+				"enter p [0] div",
+				`enter "one" [0 0] div>p`,
+				`exit "one" [0 0] div>p`,
+				"enter b [0 1] div>p",
+				`enter "two" [0 1 0] div>p>b`,
+				`exit "two" [0 1 0] div>p>b`,
+				"exit b [0 1] div>p",
+				"exit p [0] div",
+			},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		domutil.Walk(walkTree, domutil.Visitor{
+			Enter: func(c domutil.Cursor) domutil.WalkAction {
+				got = append(got, describe("enter", c))
+				if tt.enter != nil {
+					return tt.enter(c)
+				}
+				return domutil.Continue
+			},
+			Exit: func(c domutil.Cursor) domutil.WalkAction {
+				got = append(got, describe("exit", c))
+				if tt.exit != nil {
+					return tt.exit(c)
+				}
+				return domutil.Continue
+			},
+		})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot      %#v\nbut want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	oldHTML := walkTree.HTML()
+
+	var got []string
+	actual := domutil.Transform(walkTree, func(c domutil.Cursor) dom.Node {
+		got = append(got, describe("visit", c))
+		switch {
+		case c.Node.InnerText != "":
+			return dom.InnerText(strings.ToUpper(c.Node.InnerText))
+		case c.Node.Name == "b":
+			return dom.Em(c.Node.Attributes, c.Node.Children...)
+		}
+		return c.Node
+	})
+
+	want := []string{
+		`visit "one" [0 0] div>p`,
+		`visit "two" [0 1 0] div>p>b`,
+		"visit b [0 1] div>p",
+		"visit p [0] div",
+		`visit "three" [1 0 0] div>ul>li`,
+		"visit li [1 0] div>ul",
+		"visit ul [1] div",
+		`visit "four" [2] div`,
+		"visit div [] ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot      %#v\nbut want %#v", got, want)
+	}
+	if got, want := actual.HTML(), template.HTML(`<div><p>ONE<em>TWO</em></p><ul><li>THREE</li></ul>FOUR</div>`); got != want {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+	if oldHTML != walkTree.HTML() {
+		t.Errorf("Transform modified the given node")
+	}
+}
@@ -60,29 +60,26 @@ func main() {
 		tmpl.ExecuteTemplate(w, templateName, body(r.URL.Query().Get("param")).HTML())
 	})
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Add("Content-Type", "text/html")
-		w.Write([]byte(
-			dom.Html(
+	http.Handle("/", dom.Handler(func(r *http.Request) (dom.Node, error) {
+		return dom.Html(
+			dom.Attrs(),
+			dom.Head(
 				dom.Attrs(),
-				dom.Head(
+				dom.Title(
 					dom.Attrs(),
-					dom.Title(
-						dom.Attrs(),
-						dom.InnerText("Go Web"),
-					),
+					dom.InnerText("Go Web"),
 				),
-				dom.Body(
+			),
+			dom.Body(
+				dom.Attrs(),
+				dom.H1(
 					dom.Attrs(),
-					dom.H1(
-						dom.Attrs(),
-						dom.InnerText("dom-go with dom-go"),
-					),
-					body(r.URL.Query().Get("param")),
+					dom.InnerText("dom-go with dom-go"),
 				),
-			).HTML(),
-		))
-	})
+				body(r.URL.Query().Get("param")),
+			),
+		), nil
+	}))
 	log.Println("Listening on :8080...")
 	log.Println(http.ListenAndServe(":8080", nil))
 }
@@ -0,0 +1,228 @@
+package dom
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Handler is an http.Handler that writes the html of the Node returned by the
+// function, e.g.
+//
+//	http.Handle("/", dom.Handler(func(r *http.Request) (dom.Node, error) {
+//		return page(r.URL.Query().Get("q")), nil
+//	}))
+//
+// The response is `text/html; charset=utf-8`, starting with `<!DOCTYPE html>` if
+// the Node is an `<html>` element, and gzipped for clients that accept it. A 200
+// response has an ETag of its html, and a request with a matching `If-None-Match`
+// is answered 304 Not Modified.
+//
+// The function can change the status and headers of the response with SetStatus,
+// SetHeader and AddHeader. If it returns an error, an ErrorPage is written instead,
+// with the status of an HTTPError, or else 500, and without the headers set for the
+// content it failed to make, e.g. "Content-Type" and "Cache-Control".
+type Handler func(*http.Request) (Node, error)
+
+// minGzipSize is the size below which html is not worth gzipping.
+const minGzipSize = 1024
+
+// HTTPError is an error with the status of the response, and a message safe to
+// show to users. The message of other errors is not shown.
+type HTTPError struct {
+	Status  int
+	Message string // by default, the http.StatusText of Status
+	Err     error  // the cause, if any
+}
+
+func (e *HTTPError) Error() string {
+	message := e.message()
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+// Unwrap returns the cause of the error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+func (e *HTTPError) message() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return http.StatusText(e.Status)
+}
+
+// ErrorPage returns the page that Handler writes for an error.
+func ErrorPage(status int, message string) Node {
+	title := strconv.Itoa(status) + " " + http.StatusText(status)
+	return Html(Attrs(),
+		Head(Attrs(),
+			Meta(Attrs("charset", "utf-8")),
+			Title(Attrs(), InnerText(title)),
+		),
+		Body(Attrs(),
+			H1(Attrs(), InnerText(title)),
+			P(Attrs(), InnerText(message)),
+		),
+	)
+}
+
+// handlerResponse is the status and headers set by the function of a Handler.
+type handlerResponse struct {
+	status int
+	header http.Header
+}
+
+type handlerResponseKey struct{}
+
+// SetStatus sets the status of the response of a Handler to r. It does nothing
+// outside of a Handler.
+func SetStatus(r *http.Request, status int) {
+	if res, ok := r.Context().Value(handlerResponseKey{}).(*handlerResponse); ok {
+		res.status = status
+	}
+}
+
+// SetHeader sets a header of the response of a Handler to r, e.g. "Cache-Control"
+// or a "Content-Type" other than html. It does nothing outside of a Handler.
+func SetHeader(r *http.Request, key, value string) {
+	if res, ok := r.Context().Value(handlerResponseKey{}).(*handlerResponse); ok {
+		res.header.Set(key, value)
+	}
+}
+
+// AddHeader adds a value to a header of the response of a Handler to r, e.g. to
+// "Vary". It does nothing outside of a Handler.
+func AddHeader(r *http.Request, key, value string) {
+	if res, ok := r.Context().Value(handlerResponseKey{}).(*handlerResponse); ok {
+		res.header.Add(key, value)
+	}
+}
+
+// ServeHTTP writes the html of the Node returned by h, or an ErrorPage.
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	res := &handlerResponse{status: http.StatusOK, header: http.Header{}}
+	node, err := h(r.WithContext(context.WithValue(r.Context(), handlerResponseKey{}, res)))
+	if err != nil {
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) {
+			httpErr = &HTTPError{Status: http.StatusInternalServerError, Err: err}
+		}
+		res.status = validStatus(httpErr.Status)
+		message := httpErr.Message
+		if message == "" {
+			message = http.StatusText(res.status)
+		}
+		node = ErrorPage(res.status, message)
+		for _, key := range contentHeaders {
+			res.header.Del(key)
+		}
+	}
+	res.status = validStatus(res.status)
+
+	var sb strings.Builder
+	if strings.EqualFold(node.Name, "html") {
+		sb.WriteString("<!DOCTYPE html>")
+	}
+	content := []byte(node.buildHTML(&sb).String())
+
+	header := w.Header()
+	for key, values := range res.header {
+		if key == "Vary" {
+			header[key] = append(header[key], values...)
+			continue
+		}
+		header[key] = values
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	header.Add("Vary", "Accept-Encoding")
+
+	if res.status == http.StatusOK && err == nil {
+		sum := sha256.Sum256(content)
+		etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		header.Set("ETag", etag)
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && etagMatches(r.Header.Get("If-None-Match"), etag) {
+			header.Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if len(content) >= minGzipSize && acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		var gzipped bytes.Buffer
+		zw := gzip.NewWriter(&gzipped)
+		zw.Write(content) // writes to a bytes.Buffer do not fail
+		zw.Close()
+		content = gzipped.Bytes()
+		header.Set("Content-Encoding", "gzip")
+	}
+	header.Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(res.status)
+	if r.Method != http.MethodHead {
+		w.Write(content)
+	}
+}
+
+// contentHeaders are the headers about the content of a response, which do not
+// apply to an ErrorPage written instead.
+var contentHeaders = []string{
+	"Content-Type", "Content-Encoding", "Content-Language", "Content-Disposition",
+	"Cache-Control", "Expires", "ETag", "Last-Modified",
+}
+
+// validStatus returns status, or 500 if it is not 3 digits, on which net/http
+// panics.
+func validStatus(status int) int {
+	if status < 100 || status > 999 {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+// etagMatches reports whether the If-None-Match header matches etag, comparing weak
+// and strong tags alike.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip reports whether the Accept-Encoding header accepts gzip, by name or
+// else by `*`.
+func acceptsGzip(acceptEncoding string) bool {
+	star := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if f, err := strconv.ParseFloat(value, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "gzip":
+			return q > 0
+		case "*":
+			star = q > 0
+		}
+	}
+	return star
+}
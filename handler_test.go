@@ -0,0 +1,253 @@
+package dom_test
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+)
+
+func TestHandler(t *testing.T) {
+	page := dom.Html(dom.Attrs(), dom.Body(dom.Attrs(), dom.P(dom.Attrs(), dom.InnerText("hello"))))
+	large := dom.Div(dom.Attrs(), dom.InnerText(strings.Repeat("0123456789", 200)))
+	ok := func(n dom.Node) dom.Handler {
+		return func(r *http.Request) (dom.Node, error) { return n, nil }
+	}
+	etag := func(h dom.Handler) string {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		return w.Header().Get("ETag")
+	}
+
+	testCases := []struct {
+		name       string
+		handler    dom.Handler
+		method     string
+		header     map[string]string
+		wantStatus int
+		wantHeader map[string]string
+		wantBody   string
+	}{
+		{
+			name:       "page",
+			handler:    ok(page),
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Content-Type": "text/html; charset=utf-8", "Vary": "Accept-Encoding", "Content-Encoding": ""},
+			wantBody:   "<!DOCTYPE html><html><body><p>hello</p></body></html>",
+		},
+		{
+			name:       "element",
+			handler:    ok(dom.P(dom.Attrs(), dom.InnerText("a & b"))),
+			wantStatus: http.StatusOK,
+			wantBody:   "<p>a &amp; b</p>",
+		},
+		{
+			name: "status and headers",
+			handler: func(r *http.Request) (dom.Node, error) {
+				dom.SetStatus(r, http.StatusCreated)
+				dom.SetHeader(r, "Cache-Control", "no-store")
+				dom.SetHeader(r, "Content-Type", "image/svg+xml")
+				return dom.Element("svg", dom.Attrs()), nil
+			},
+			wantStatus: http.StatusCreated,
+			wantHeader: map[string]string{"Cache-Control": "no-store", "Content-Type": "image/svg+xml", "ETag": ""},
+			wantBody:   "<svg></svg>",
+		},
+		{
+			name:       "if-none-match",
+			handler:    ok(page),
+			header:     map[string]string{"If-None-Match": `"other", ` + etag(ok(page))},
+			wantStatus: http.StatusNotModified,
+			wantHeader: map[string]string{"ETag": etag(ok(page)), "Content-Type": ""},
+		},
+		{
+			name:       "if-none-match strong",
+			handler:    ok(page),
+			header:     map[string]string{"If-None-Match": strings.TrimPrefix(etag(ok(page)), "W/")},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "if-none-match any",
+			handler:    ok(page),
+			header:     map[string]string{"If-None-Match": "*"},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "if-none-match changed",
+			handler:    ok(page),
+			header:     map[string]string{"If-None-Match": etag(ok(large))},
+			wantStatus: http.StatusOK,
+			wantBody:   "<!DOCTYPE html><html><body><p>hello</p></body></html>",
+		},
+		{
+			name:       "if-none-match post",
+			handler:    ok(page),
+			method:     "POST",
+			header:     map[string]string{"If-None-Match": "*"},
+			wantStatus: http.StatusOK,
+			wantBody:   "<!DOCTYPE html><html><body><p>hello</p></body></html>",
+		},
+		{
+			name:       "gzip",
+			handler:    ok(large),
+			header:     map[string]string{"Accept-Encoding": "br, gzip;q=0.8"},
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Content-Encoding": "gzip"},
+			wantBody:   string(large.HTML()),
+		},
+		{
+			name:       "gzip by star",
+			handler:    ok(large),
+			header:     map[string]string{"Accept-Encoding": "*"},
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Content-Encoding": "gzip"},
+			wantBody:   string(large.HTML()),
+		},
+		{
+			name:       "gzip refused",
+			handler:    ok(large),
+			header:     map[string]string{"Accept-Encoding": "gzip;q=0, *"},
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Content-Encoding": ""},
+			wantBody:   string(large.HTML()),
+		},
+		{
+			name:       "gzip too small",
+			handler:    ok(page),
+			header:     map[string]string{"Accept-Encoding": "gzip"},
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Content-Encoding": ""},
+			wantBody:   "<!DOCTYPE html><html><body><p>hello</p></body></html>",
+		},
+		{
+			name:       "head",
+			handler:    ok(page),
+			method:     "HEAD",
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Content-Length": "53"},
+		},
+		{
+			name: "http error",
+			handler: func(r *http.Request) (dom.Node, error) {
+				return dom.Node{}, fmt.Errorf("finding: %w", &dom.HTTPError{Status: http.StatusNotFound, Message: "No such <item>", Err: errors.New("sql: no rows")})
+			},
+			wantStatus: http.StatusNotFound,
+			wantHeader: map[string]string{"ETag": "", "Content-Type": "text/html; charset=utf-8"},
+			wantBody:   "<!DOCTYPE html>" + string(dom.ErrorPage(http.StatusNotFound, "No such <item>").HTML()),
+		},
+		{
+			name: "http error without message",
+			handler: func(r *http.Request) (dom.Node, error) {
+				return dom.Node{}, &dom.HTTPError{Status: http.StatusForbidden}
+			},
+			wantStatus: http.StatusForbidden,
+			wantBody:   "<!DOCTYPE html>" + string(dom.ErrorPage(http.StatusForbidden, "Forbidden").HTML()),
+		},
+		{
+			name: "error after headers",
+			handler: func(r *http.Request) (dom.Node, error) {
+				dom.SetHeader(r, "Content-Type", "image/svg+xml")
+				dom.SetHeader(r, "Cache-Control", "max-age=3600")
+				dom.SetHeader(r, "Set-Cookie", "seen=1")
+				return dom.Node{}, &dom.HTTPError{Status: http.StatusNotFound}
+			},
+			wantStatus: http.StatusNotFound,
+			wantHeader: map[string]string{"Content-Type": "text/html; charset=utf-8", "Cache-Control": "", "Set-Cookie": "seen=1"},
+			wantBody:   "<!DOCTYPE html>" + string(dom.ErrorPage(http.StatusNotFound, "Not Found").HTML()),
+		},
+		{
+			name: "http error without status",
+			handler: func(r *http.Request) (dom.Node, error) {
+				return dom.Node{}, &dom.HTTPError{Err: errors.New("oops")}
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "<!DOCTYPE html>" + string(dom.ErrorPage(http.StatusInternalServerError, "Internal Server Error").HTML()),
+		},
+		{
+			name: "invalid status",
+			handler: func(r *http.Request) (dom.Node, error) {
+				dom.SetStatus(r, 0)
+				return dom.P(dom.Attrs(), dom.InnerText("hello")), nil
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantHeader: map[string]string{"ETag": ""},
+			wantBody:   "<p>hello</p>",
+		},
+		{
+			name: "error",
+			handler: func(r *http.Request) (dom.Node, error) {
+				dom.SetStatus(r, http.StatusCreated)
+				return dom.Node{}, errors.New("password=secret")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantHeader: map[string]string{"ETag": ""},
+			wantBody:   "<!DOCTYPE html>" + string(dom.ErrorPage(http.StatusInternalServerError, "Internal Server Error").HTML()),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = "GET"
+			}
+			r := httptest.NewRequest(method, "/", nil)
+			for key, value := range tc.header {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			tc.handler.ServeHTTP(w, r)
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("\ngot      %d\nbut want %d", w.Code, tc.wantStatus)
+			}
+			for key, want := range tc.wantHeader {
+				if got := w.Header().Get(key); got != want {
+					t.Errorf("%s:\ngot      %q\nbut want %q", key, got, want)
+				}
+			}
+			var body io.Reader = w.Body
+			if w.Header().Get("Content-Encoding") == "gzip" {
+				if w.Header().Get("Content-Length") != fmt.Sprint(w.Body.Len()) {
+					t.Errorf("Content-Length %q of %d bytes", w.Header().Get("Content-Length"), w.Body.Len())
+				}
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = zr
+			}
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.wantBody {
+				t.Errorf("\ngot      %q\nbut want %q", got, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandlerOutside(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	dom.SetStatus(r, http.StatusTeapot) // does nothing, and does not panic
+	dom.SetHeader(r, "X-Test", "1")
+}
+
+func TestHTTPError(t *testing.T) {
+	cause := errors.New("sql: no rows")
+	err := error(&dom.HTTPError{Status: http.StatusNotFound, Err: cause})
+	if got, want := err.Error(), "Not Found: sql: no rows"; got != want {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("%v does not wrap %v", err, cause)
+	}
+}
@@ -0,0 +1,229 @@
+// Package htmx has typed helpers for the hx-* attributes of htmx, and for the
+// headers of htmx requests and responses.
+//
+// Attributes are appended to the others of an element:
+//
+//	dom.Button(append(dom.Attrs("class", "btn"),
+//		htmx.Post("/cart"),
+//		htmx.Target("#cart"),
+//		htmx.Swap(htmx.SwapOuterHTML, htmx.Transition()),
+//		htmx.Trigger(htmx.On("click").Once()),
+//	), dom.InnerText("Add to cart"))
+//
+// See https://htmx.org/reference/
+package htmx
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// Attrs returns the attributes as a slice, for elements with no other attributes.
+func Attrs(attrs ...dom.Attribute) []dom.Attribute {
+	return attrs
+}
+
+func attr(name, value string) dom.Attribute {
+	return dom.Attribute{Name: name, ValueText: value}
+}
+
+// Get returns `hx-get`, to issue a GET request to url.
+func Get(url string) dom.Attribute { return attr("hx-get", url) }
+
+// Post returns `hx-post`, to issue a POST request to url.
+func Post(url string) dom.Attribute { return attr("hx-post", url) }
+
+// Put returns `hx-put`, to issue a PUT request to url.
+func Put(url string) dom.Attribute { return attr("hx-put", url) }
+
+// Patch returns `hx-patch`, to issue a PATCH request to url.
+func Patch(url string) dom.Attribute { return attr("hx-patch", url) }
+
+// Delete returns `hx-delete`, to issue a DELETE request to url.
+func Delete(url string) dom.Attribute { return attr("hx-delete", url) }
+
+// Target returns `hx-target`, the element to swap the response into, e.g. "#cart",
+// "this" or "closest tr".
+func Target(selector string) dom.Attribute { return attr("hx-target", selector) }
+
+// Select returns `hx-select`, the part of the response to swap in.
+func Select(selector string) dom.Attribute { return attr("hx-select", selector) }
+
+// SelectOOB returns `hx-select-oob`, the parts of the response to swap out of band,
+// e.g. "#alert" or "#alert:afterbegin".
+func SelectOOB(selectors ...string) dom.Attribute {
+	return attr("hx-select-oob", strings.Join(selectors, ","))
+}
+
+// PushURL returns `hx-push-url`, the url to push into the browser history: "true"
+// for the url of the request, "false", or a url.
+func PushURL(url string) dom.Attribute { return attr("hx-push-url", url) }
+
+// ReplaceURL returns `hx-replace-url`, like PushURL but replacing the current entry
+// of the browser history.
+func ReplaceURL(url string) dom.Attribute { return attr("hx-replace-url", url) }
+
+// Confirm returns `hx-confirm`, a message to confirm before the request.
+func Confirm(message string) dom.Attribute { return attr("hx-confirm", message) }
+
+// Prompt returns `hx-prompt`, a message to prompt for the HX-Prompt header.
+func Prompt(message string) dom.Attribute { return attr("hx-prompt", message) }
+
+// Indicator returns `hx-indicator`, the element to add the `htmx-request` class to
+// during the request.
+func Indicator(selector string) dom.Attribute { return attr("hx-indicator", selector) }
+
+// Include returns `hx-include`, other elements whose values are sent.
+func Include(selector string) dom.Attribute { return attr("hx-include", selector) }
+
+// Params returns `hx-params`, the parameters to send: "*", "none", "not a,b" or "a,b".
+func Params(params string) dom.Attribute { return attr("hx-params", params) }
+
+// Vals returns `hx-vals`, values to send with the request, as JSON.
+func Vals(vals map[string]string) dom.Attribute { return attr("hx-vals", jsonObject(vals)) }
+
+// Headers returns `hx-headers`, headers to send with the request, as JSON.
+func Headers(headers map[string]string) dom.Attribute {
+	return attr("hx-headers", jsonObject(headers))
+}
+
+// Boost returns `hx-boost`, to turn links and forms into htmx requests.
+func Boost(boost bool) dom.Attribute { return attr("hx-boost", strconv.FormatBool(boost)) }
+
+// Disable returns `hx-disable`, to ignore the htmx attributes of the element and its
+// children.
+func Disable() dom.Attribute { return attr("hx-disable", "") }
+
+// DisabledElt returns `hx-disabled-elt`, the elements to disable during the request.
+func DisabledElt(selector string) dom.Attribute { return attr("hx-disabled-elt", selector) }
+
+// Disinherit returns `hx-disinherit`, the attributes that children do not inherit,
+// e.g. "hx-target" or "*".
+func Disinherit(attrs ...string) dom.Attribute {
+	return attr("hx-disinherit", strings.Join(attrs, " "))
+}
+
+// Inherit returns `hx-inherit`, the attributes that children inherit when
+// inheritance is off by default.
+func Inherit(attrs ...string) dom.Attribute { return attr("hx-inherit", strings.Join(attrs, " ")) }
+
+// Encoding returns `hx-encoding`, e.g. "multipart/form-data" for file uploads.
+func Encoding(encoding string) dom.Attribute { return attr("hx-encoding", encoding) }
+
+// Ext returns `hx-ext`, the extensions to use.
+func Ext(extensions ...string) dom.Attribute { return attr("hx-ext", strings.Join(extensions, ",")) }
+
+// History returns `hx-history`; false keeps the page out of the history cache.
+func History(history bool) dom.Attribute { return attr("hx-history", strconv.FormatBool(history)) }
+
+// HistoryElt returns `hx-history-elt`, the element to snapshot for the history
+// instead of the body.
+func HistoryElt() dom.Attribute { return attr("hx-history-elt", "") }
+
+// Preserve returns `hx-preserve`, to keep the element unchanged between swaps. It
+// needs an id.
+func Preserve() dom.Attribute { return attr("hx-preserve", "") }
+
+// Request returns `hx-request`, to configure the request, e.g. `"timeout": 500`.
+func Request(config string) dom.Attribute { return attr("hx-request", config) }
+
+// Sync returns `hx-sync`, to synchronize the requests of elements, e.g.
+// Sync("closest form", "abort").
+func Sync(selector, strategy string) dom.Attribute {
+	if strategy == "" {
+		return attr("hx-sync", selector)
+	}
+	return attr("hx-sync", selector+":"+strategy)
+}
+
+// Validate returns `hx-validate`, to validate the element before the request.
+func Validate() dom.Attribute { return attr("hx-validate", "true") }
+
+// OnEvent returns `hx-on:event`, a script to run on the event, e.g.
+// OnEvent("htmx:after-request", "this.reset()").
+func OnEvent(event, script string) dom.Attribute { return attr("hx-on:"+event, script) }
+
+// SwapStyle is how the response is swapped in.
+type SwapStyle string
+
+// Swap styles.
+const (
+	SwapInnerHTML   SwapStyle = "innerHTML"
+	SwapOuterHTML   SwapStyle = "outerHTML"
+	SwapTextContent SwapStyle = "textContent"
+	SwapBeforeBegin SwapStyle = "beforebegin"
+	SwapAfterBegin  SwapStyle = "afterbegin"
+	SwapBeforeEnd   SwapStyle = "beforeend"
+	SwapAfterEnd    SwapStyle = "afterend"
+	SwapDelete      SwapStyle = "delete"
+	SwapNone        SwapStyle = "none"
+)
+
+// SwapModifier changes how the response is swapped in.
+type SwapModifier string
+
+// Swap returns `hx-swap`, how the response is swapped in.
+func Swap(style SwapStyle, modifiers ...SwapModifier) dom.Attribute {
+	parts := []string{string(style)}
+	for _, m := range modifiers {
+		parts = append(parts, string(m))
+	}
+	return attr("hx-swap", strings.Join(parts, " "))
+}
+
+// SwapDelay waits d before swapping.
+func SwapDelay(d time.Duration) SwapModifier { return SwapModifier("swap:" + duration(d)) }
+
+// SettleDelay waits d between swapping and settling.
+func SettleDelay(d time.Duration) SwapModifier { return SwapModifier("settle:" + duration(d)) }
+
+// Transition uses the View Transitions API.
+func Transition() SwapModifier { return "transition:true" }
+
+// IgnoreTitle keeps the page title, instead of the `<title>` of the response.
+func IgnoreTitle() SwapModifier { return "ignoreTitle:true" }
+
+// FocusScroll scrolls to the focused element, or not.
+func FocusScroll(scroll bool) SwapModifier {
+	return SwapModifier("focus-scroll:" + strconv.FormatBool(scroll))
+}
+
+// Scroll scrolls the target, or the element of selector if given, to "top" or
+// "bottom".
+func Scroll(position, selector string) SwapModifier {
+	return SwapModifier("scroll:" + withSelector(position, selector))
+}
+
+// Show scrolls the target, or the element of selector if given, into view at "top"
+// or "bottom"; "none" does not.
+func Show(position, selector string) SwapModifier {
+	return SwapModifier("show:" + withSelector(position, selector))
+}
+
+func withSelector(position, selector string) string {
+	if selector == "" {
+		return position
+	}
+	return selector + ":" + position
+}
+
+// duration formats d as htmx reads it, e.g. "500ms" or "2s".
+func duration(d time.Duration) string {
+	if d%time.Second == 0 {
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+// jsonObject returns m as a JSON object, with its keys sorted.
+func jsonObject(m map[string]string) string {
+	if m == nil {
+		return "{}"
+	}
+	b, _ := json.Marshal(m) // a map of strings always marshals
+	return string(b)
+}
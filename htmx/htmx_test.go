@@ -0,0 +1,99 @@
+package htmx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/htmx"
+)
+
+func TestAttributes(t *testing.T) {
+	tests := []struct {
+		name  string
+		given dom.Node
+		want  string
+	}{
+		{
+			name: "request",
+			given: dom.Button(append(dom.Attrs("class", "btn"),
+				htmx.Post("/cart?item=1&qty=2"),
+				htmx.Target("closest tr"),
+				htmx.Swap(htmx.SwapOuterHTML),
+				htmx.Confirm(`Add "it"?`),
+			), dom.InnerText("Add")),
+			want: `<button class="btn" hx-post="/cart?item=1&amp;qty=2" hx-target="closest tr" hx-swap="outerHTML" hx-confirm="Add &#34;it&#34;?">Add</button>`,
+		},
+		{
+			name: "methods",
+			given: dom.Div(htmx.Attrs(
+				htmx.Get("/a"), htmx.Put("/b"), htmx.Patch("/c"), htmx.Delete("/d"),
+			)),
+			want: `<div hx-get="/a" hx-put="/b" hx-patch="/c" hx-delete="/d"></div>`,
+		},
+		{
+			name: "swap modifiers",
+			given: dom.Div(htmx.Attrs(
+				htmx.Swap(htmx.SwapBeforeEnd,
+					htmx.SwapDelay(500*time.Millisecond),
+					htmx.SettleDelay(2*time.Second),
+					htmx.Transition(),
+					htmx.IgnoreTitle(),
+					htmx.FocusScroll(false),
+					htmx.Scroll("bottom", ""),
+					htmx.Show("top", "#list"),
+				),
+			)),
+			want: `<div hx-swap="beforeend swap:500ms settle:2s transition:true ignoreTitle:true focus-scroll:false scroll:bottom show:#list:top"></div>`,
+		},
+		{
+			name: "values",
+			given: dom.Form(htmx.Attrs(
+				htmx.Vals(map[string]string{"b": "2", "a": `"1"`}),
+				htmx.Headers(nil),
+				htmx.Params("not secret"),
+				htmx.Include("[name=q]"),
+				htmx.Encoding("multipart/form-data"),
+				htmx.Sync("closest form", "abort"),
+				htmx.Sync("this", ""),
+			)),
+			want: `<form hx-vals="{&#34;a&#34;:&#34;\&#34;1\&#34;&#34;,&#34;b&#34;:&#34;2&#34;}" hx-headers="{}" hx-params="not secret" hx-include="[name=q]" hx-encoding="multipart/form-data" hx-sync="closest form:abort" hx-sync="this"></form>`,
+		},
+		{
+			name: "page",
+			given: dom.Body(htmx.Attrs(
+				htmx.Boost(true),
+				htmx.PushURL("true"),
+				htmx.ReplaceURL("/x"),
+				htmx.Ext("sse", "ws"),
+				htmx.Disinherit("hx-target", "hx-swap"),
+				htmx.Inherit("*"),
+				htmx.History(false),
+				htmx.HistoryElt(),
+				htmx.Select("#main"),
+				htmx.SelectOOB("#alert", "#nav:afterbegin"),
+				htmx.Indicator("#spinner"),
+				htmx.DisabledElt("this"),
+				htmx.Prompt("Name?"),
+				htmx.Request(`"timeout": 500`),
+			)),
+			want: `<body hx-boost="true" hx-push-url="true" hx-replace-url="/x" hx-ext="sse,ws" hx-disinherit="hx-target hx-swap" hx-inherit="*" hx-history="false" hx-history-elt="" hx-select="#main" hx-select-oob="#alert,#nav:afterbegin" hx-indicator="#spinner" hx-disabled-elt="this" hx-prompt="Name?" hx-request="&#34;timeout&#34;: 500"></body>`,
+		},
+		{
+			name: "flags and scripts",
+			given: dom.Div(htmx.Attrs(
+				htmx.Disable(), htmx.Preserve(), htmx.Validate(),
+				htmx.OnEvent("htmx:after-request", "this.reset()"),
+			)),
+			want: `<div hx-disable="" hx-preserve="" hx-validate="true" hx-on:htmx:after-request="this.reset()"></div>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(tt.given.HTML())
+			if got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+		})
+	}
+}
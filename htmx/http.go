@@ -0,0 +1,141 @@
+package htmx
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// IsRequest reports whether r is made by htmx. Responses that depend on it should
+// also `Vary: HX-Request`.
+func IsRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// IsBoosted reports whether r is made by an element with `hx-boost`.
+func IsBoosted(r *http.Request) bool {
+	return r.Header.Get("HX-Boosted") == "true"
+}
+
+// IsHistoryRestoreRequest reports whether r is for the page to restore from the
+// history, after a miss in the history cache.
+func IsHistoryRestoreRequest(r *http.Request) bool {
+	return r.Header.Get("HX-History-Restore-Request") == "true"
+}
+
+// RequestTarget returns the id of the target element, if it has one.
+func RequestTarget(r *http.Request) string {
+	return r.Header.Get("HX-Target")
+}
+
+// RequestTrigger returns the id of the element that triggered the request, if it
+// has one.
+func RequestTrigger(r *http.Request) string {
+	return r.Header.Get("HX-Trigger")
+}
+
+// RequestTriggerName returns the name of the element that triggered the request,
+// if it has one.
+func RequestTriggerName(r *http.Request) string {
+	return r.Header.Get("HX-Trigger-Name")
+}
+
+// CurrentURL returns the url of the page that made the request.
+func CurrentURL(r *http.Request) string {
+	return r.Header.Get("HX-Current-URL")
+}
+
+// PromptResponse returns the answer to the `hx-prompt` of the element.
+func PromptResponse(r *http.Request) string {
+	return r.Header.Get("HX-Prompt")
+}
+
+// SetRedirect makes the page go to url, with a full page load.
+func SetRedirect(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Redirect", url)
+}
+
+// SetLocation makes the page go to url, with an htmx request instead of a full page
+// load.
+func SetLocation(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Location", url)
+}
+
+// SetRefresh makes the page reload.
+func SetRefresh(w http.ResponseWriter) {
+	w.Header().Set("HX-Refresh", "true")
+}
+
+// SetPushURL pushes url into the browser history, or nothing if url is "false".
+func SetPushURL(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Push-Url", url)
+}
+
+// SetReplaceURL replaces the current url in the browser history with url.
+func SetReplaceURL(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Replace-Url", url)
+}
+
+// SetReswap changes how the response is swapped in, instead of the `hx-swap` of
+// the element.
+func SetReswap(w http.ResponseWriter, style SwapStyle, modifiers ...SwapModifier) {
+	w.Header().Set("HX-Reswap", Swap(style, modifiers...).ValueText)
+}
+
+// SetRetarget changes the element the response is swapped into.
+func SetRetarget(w http.ResponseWriter, selector string) {
+	w.Header().Set("HX-Retarget", selector)
+}
+
+// SetReselect changes the part of the response that is swapped in.
+func SetReselect(w http.ResponseWriter, selector string) {
+	w.Header().Set("HX-Reselect", selector)
+}
+
+// Events are the events to trigger in the page by name, with the `detail` of each
+// event; a nil detail is null.
+type Events map[string]any
+
+// SetTrigger triggers events in the page as soon as the response is received. It
+// returns an error if a detail cannot be encoded as JSON.
+func SetTrigger(w http.ResponseWriter, events Events) error {
+	return setEvents(w, "HX-Trigger", events)
+}
+
+// SetTriggerAfterSwap is like SetTrigger, but after the response is swapped in.
+func SetTriggerAfterSwap(w http.ResponseWriter, events Events) error {
+	return setEvents(w, "HX-Trigger-After-Swap", events)
+}
+
+// SetTriggerAfterSettle is like SetTrigger, but after the response has settled.
+func SetTriggerAfterSettle(w http.ResponseWriter, events Events) error {
+	return setEvents(w, "HX-Trigger-After-Settle", events)
+}
+
+func setEvents(w http.ResponseWriter, header string, events Events) error {
+	b, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(header, string(b))
+	return nil
+}
+
+// OOB returns n to be swapped in out of band, i.e. into the element of the page
+// with the same id, besides the target of the response. With a style, it is swapped
+// in that way, and with a selector, into those elements instead; without a style,
+// it replaces them, like SwapOuterHTML.
+func OOB(n dom.Node, style SwapStyle, selector string) dom.Node {
+	value := "true"
+	switch {
+	case selector != "":
+		if style == "" {
+			style = SwapOuterHTML // what "true" means, which htmx does not take with a selector
+		}
+		value = string(style) + ":" + selector
+	case style != "":
+		value = string(style)
+	}
+	return n.SetAttr("hx-swap-oob", value)
+}
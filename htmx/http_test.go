@@ -0,0 +1,102 @@
+package htmx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/htmx"
+)
+
+func TestRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if htmx.IsRequest(r) || htmx.IsBoosted(r) || htmx.IsHistoryRestoreRequest(r) {
+		t.Errorf("plain request is detected as htmx")
+	}
+
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Boosted", "true")
+	r.Header.Set("HX-History-Restore-Request", "true")
+	r.Header.Set("HX-Target", "cart")
+	r.Header.Set("HX-Trigger", "add")
+	r.Header.Set("HX-Trigger-Name", "item")
+	r.Header.Set("HX-Current-URL", "http://example.com/shop")
+	r.Header.Set("HX-Prompt", "Alice")
+	if !htmx.IsRequest(r) || !htmx.IsBoosted(r) || !htmx.IsHistoryRestoreRequest(r) {
+		t.Errorf("htmx request is not detected")
+	}
+	got := []string{htmx.RequestTarget(r), htmx.RequestTrigger(r), htmx.RequestTriggerName(r), htmx.CurrentURL(r), htmx.PromptResponse(r)}
+	want := []string{"cart", "add", "item", "http://example.com/shop", "Alice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+}
+
+func TestResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	htmx.SetRedirect(w, "/login")
+	htmx.SetLocation(w, "/cart")
+	htmx.SetRefresh(w)
+	htmx.SetPushURL(w, "/cart?page=2")
+	htmx.SetReplaceURL(w, "false")
+	htmx.SetReswap(w, htmx.SwapInnerHTML, htmx.Transition())
+	htmx.SetRetarget(w, "#errors")
+	htmx.SetReselect(w, "#main")
+	if err := htmx.SetTrigger(w, htmx.Events{"cart-updated": map[string]int{"count": 3}, "notice": "Saved"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := htmx.SetTriggerAfterSwap(w, htmx.Events{"focus": nil}); err != nil {
+		t.Fatal(err)
+	}
+	if err := htmx.SetTriggerAfterSettle(w, htmx.Events{"done": true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := htmx.SetTrigger(w, htmx.Events{"bad": func() {}}); err == nil {
+		t.Errorf("got no error for a detail that is not JSON")
+	}
+
+	want := http.Header{
+		"Hx-Redirect":             {"/login"},
+		"Hx-Location":             {"/cart"},
+		"Hx-Refresh":              {"true"},
+		"Hx-Push-Url":             {"/cart?page=2"},
+		"Hx-Replace-Url":          {"false"},
+		"Hx-Reswap":               {"innerHTML transition:true"},
+		"Hx-Retarget":             {"#errors"},
+		"Hx-Reselect":             {"#main"},
+		"Hx-Trigger":              {`{"cart-updated":{"count":3},"notice":"Saved"}`},
+		"Hx-Trigger-After-Swap":   {`{"focus":null}`},
+		"Hx-Trigger-After-Settle": {`{"done":true}`},
+	}
+	if !reflect.DeepEqual(w.Header(), want) {
+		t.Errorf("\ngot      %q\nbut want %q", w.Header(), want)
+	}
+}
+
+func TestOOB(t *testing.T) {
+	badge := dom.Span(dom.Attrs("id", "count"), dom.InnerText("3"))
+	tests := []struct {
+		name     string
+		style    htmx.SwapStyle
+		selector string
+		want     string
+	}{
+		{name: "by id", want: `<span id="count" hx-swap-oob="true">3</span>`},
+		{name: "style", style: htmx.SwapOuterHTML, want: `<span id="count" hx-swap-oob="outerHTML">3</span>`},
+		{name: "style and selector", style: htmx.SwapBeforeEnd, selector: "#badges", want: `<span id="count" hx-swap-oob="beforeend:#badges">3</span>`},
+		{name: "selector", selector: ".count", want: `<span id="count" hx-swap-oob="outerHTML:.count">3</span>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(htmx.OOB(badge, tt.style, tt.selector).HTML())
+			if got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+			if badge.HTML() != `<span id="count">3</span>` {
+				t.Errorf("given node was modified: %q", badge.HTML())
+			}
+		})
+	}
+}
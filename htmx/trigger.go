@@ -0,0 +1,87 @@
+package htmx
+
+import (
+	"strings"
+	"time"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// TriggerSpec is an event that triggers the request of an element, with its
+// filter and modifiers. Make one with On or Every.
+type TriggerSpec struct {
+	event     string
+	filter    string
+	modifiers []string
+}
+
+// On triggers on the event, e.g. "click", "keyup", "load", "revealed" or
+// "intersect".
+func On(event string) TriggerSpec {
+	return TriggerSpec{event: event}
+}
+
+// Every triggers on a timer, every d.
+func Every(d time.Duration) TriggerSpec {
+	return TriggerSpec{event: "every " + duration(d)}
+}
+
+// Trigger returns `hx-trigger`, the events that trigger the request.
+func Trigger(specs ...TriggerSpec) dom.Attribute {
+	parts := make([]string, len(specs))
+	for i, spec := range specs {
+		parts[i] = spec.String()
+	}
+	return attr("hx-trigger", strings.Join(parts, ", "))
+}
+
+// String returns the spec as written in `hx-trigger`, e.g. "keyup changed delay:500ms".
+func (t TriggerSpec) String() string {
+	var sb strings.Builder
+	sb.WriteString(t.event)
+	if t.filter != "" {
+		sb.WriteString("[" + t.filter + "]")
+	}
+	for _, m := range t.modifiers {
+		sb.WriteString(" " + m)
+	}
+	return sb.String()
+}
+
+func (t TriggerSpec) with(modifier string) TriggerSpec {
+	t.modifiers = append(t.modifiers[:len(t.modifiers):len(t.modifiers)], modifier)
+	return t
+}
+
+// Filter only triggers when the JavaScript expression is true, e.g. "ctrlKey".
+func (t TriggerSpec) Filter(expr string) TriggerSpec {
+	t.filter = expr
+	return t
+}
+
+// Once only triggers the first time.
+func (t TriggerSpec) Once() TriggerSpec { return t.with("once") }
+
+// Changed only triggers when the value of the element has changed.
+func (t TriggerSpec) Changed() TriggerSpec { return t.with("changed") }
+
+// Delay waits d before triggering, starting over on every event.
+func (t TriggerSpec) Delay(d time.Duration) TriggerSpec { return t.with("delay:" + duration(d)) }
+
+// Throttle triggers at most once every d.
+func (t TriggerSpec) Throttle(d time.Duration) TriggerSpec {
+	return t.with("throttle:" + duration(d))
+}
+
+// From listens for the event on other elements, e.g. "body" or "closest form".
+func (t TriggerSpec) From(selector string) TriggerSpec { return t.with("from:" + selector) }
+
+// Target only triggers for events on the elements of selector.
+func (t TriggerSpec) Target(selector string) TriggerSpec { return t.with("target:" + selector) }
+
+// Consume stops the event from triggering the requests of the parent elements.
+func (t TriggerSpec) Consume() TriggerSpec { return t.with("consume") }
+
+// Queue is which events to queue while a request is in flight: "first", "last",
+// "all" or "none".
+func (t TriggerSpec) Queue(queue string) TriggerSpec { return t.with("queue:" + queue) }
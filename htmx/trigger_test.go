@@ -0,0 +1,54 @@
+package htmx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/htmx"
+)
+
+func TestTrigger(t *testing.T) {
+	tests := []struct {
+		name  string
+		given dom.Attribute
+		want  string
+	}{
+		{
+			name:  "event",
+			given: htmx.Trigger(htmx.On("click")),
+			want:  "click",
+		},
+		{
+			name:  "search as you type",
+			given: htmx.Trigger(htmx.On("keyup").Changed().Delay(500*time.Millisecond), htmx.On("search")),
+			want:  "keyup changed delay:500ms, search",
+		},
+		{
+			name:  "filter and modifiers",
+			given: htmx.Trigger(htmx.On("click").Filter("ctrlKey").Once().Throttle(time.Second).From("body").Target("button").Consume().Queue("last")),
+			want:  "click[ctrlKey] once throttle:1s from:body target:button consume queue:last",
+		},
+		{
+			name:  "polling",
+			given: htmx.Trigger(htmx.Every(2 * time.Second)),
+			want:  "every 2s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.given.Name != "hx-trigger" || tt.given.ValueText != tt.want {
+				t.Errorf("\ngot      %q=%q\nbut want %q", tt.given.Name, tt.given.ValueText, tt.want)
+			}
+		})
+	}
+}
+
+func TestTriggerSpecIsImmutable(t *testing.T) {
+	keyup := htmx.On("keyup").Changed()
+	a := keyup.Delay(time.Second)
+	b := keyup.Once()
+	if got, want := a.String()+", "+b.String(), "keyup changed delay:1s, keyup changed once"; got != want {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+}
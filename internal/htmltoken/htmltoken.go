@@ -0,0 +1,156 @@
+// Package htmltoken splits fragments of html into tokens, for the packages of this
+// module that read InnerHTML.
+package htmltoken
+
+import "strings"
+
+// Kind is the kind of a Token.
+type Kind int
+
+const (
+	Text     Kind = iota // text content, still escaped
+	StartTag             // e.g. `<a href="...">` or `<br/>`
+	EndTag               // e.g. `</a>`
+	RawText              // the contents of `<script>` or `<style>`
+	Other                // comments, doctypes and the like
+)
+
+// Token is a part of a fragment of html, at s[Start:End].
+type Token struct {
+	Kind       Kind
+	Start, End int
+	Name       string // lower cased tag name of start and end tags
+	SelfClose  bool   // a start tag ending with `/>`
+}
+
+// Tokenize splits a fragment of html into tokens. It does not validate or fix
+// anything: it only tells the markup from the text content, including inside
+// attribute values, comments and `<script>` and `<style>`.
+func Tokenize(s string) []Token {
+	var tokens []Token
+	textStart := 0
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			i++
+			continue
+		}
+		token, ok := markupAt(s, i)
+		if !ok {
+			i++ // not markup, e.g. "1 < 2"
+			continue
+		}
+		if i > textStart {
+			tokens = append(tokens, Token{Kind: Text, Start: textStart, End: i})
+		}
+		tokens = append(tokens, token)
+		i, textStart = token.End, token.End
+
+		if token.Kind == StartTag && !token.SelfClose && (token.Name == "script" || token.Name == "style") {
+			end := IndexFold(s[i:], "</"+token.Name)
+			if end < 0 {
+				end = len(s) - i
+			}
+			if end > 0 {
+				tokens = append(tokens, Token{Kind: RawText, Start: i, End: i + end})
+			}
+			i, textStart = i+end, i+end
+		}
+	}
+	if textStart < len(s) {
+		tokens = append(tokens, Token{Kind: Text, Start: textStart, End: len(s)})
+	}
+	return tokens
+}
+
+// markupAt returns the markup token starting with the `<` at s[i], and false if it
+// does not start markup. Unterminated markup extends to the end of s.
+func markupAt(s string, i int) (Token, bool) {
+	rest := s[i:]
+	switch {
+	case strings.HasPrefix(rest, "<!--"):
+		end := strings.Index(rest[4:], "-->")
+		if end < 0 {
+			return Token{Kind: Other, Start: i, End: len(s)}, true
+		}
+		return Token{Kind: Other, Start: i, End: i + 4 + end + 3}, true
+	case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return Token{Kind: Other, Start: i, End: len(s)}, true
+		}
+		return Token{Kind: Other, Start: i, End: i + end + 1}, true
+	case len(rest) > 2 && rest[1] == '/' && isASCIILetter(rest[2]):
+		token := Token{Kind: EndTag, Start: i, End: len(s), Name: tagName(rest[2:])}
+		if end := strings.IndexByte(rest, '>'); end >= 0 {
+			token.End = i + end + 1
+		}
+		return token, true
+	case len(rest) > 1 && isASCIILetter(rest[1]):
+		token := Token{Kind: StartTag, Start: i, End: len(s), Name: tagName(rest[1:])}
+		var quote byte
+		for j := 1 + len(token.Name); j < len(rest); j++ {
+			switch c := rest[j]; {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '\'':
+				quote = c
+			case c == '>':
+				token.End = i + j + 1
+				token.SelfClose = rest[j-1] == '/'
+				return token, true
+			}
+		}
+		return token, true
+	}
+	return Token{}, false
+}
+
+// tagName returns the lower cased tag name that s starts with.
+func tagName(s string) string {
+	end := strings.IndexAny(s, " \t\n\r\f/>")
+	if end < 0 {
+		end = len(s)
+	}
+	return toLowerASCII(s[:end])
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// IndexFold is strings.Index ignoring ASCII case. Unlike searching a lower cased
+// copy, the index is into s, whatever the runes of s.
+func IndexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if equalFoldASCII(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func equalFoldASCII(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		if lowerASCII(a[i]) != lowerASCII(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func toLowerASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		b[i] = lowerASCII(c)
+	}
+	return string(b)
+}
+
+func lowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,73 @@
+package htmltoken_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/choonkeat/dom-go/internal/htmltoken"
+)
+
+func TestTokenize(t *testing.T) {
+	type token struct {
+		Kind htmltoken.Kind
+		Text string
+		Name string
+	}
+	tests := []struct {
+		name  string
+		given string
+		want  []token
+	}{
+		{
+			name:  "tags and text",
+			given: `a < b<P class="x>y">c<BR/></p>`,
+			want: []token{
+				{htmltoken.Text, "a < b", ""},
+				{htmltoken.StartTag, `<P class="x>y">`, "p"},
+				{htmltoken.Text, "c", ""},
+				{htmltoken.StartTag, "<BR/>", "br"},
+				{htmltoken.EndTag, "</p>", "p"},
+			},
+		},
+		{
+			name:  "comments and doctypes",
+			given: "<!DOCTYPE html><!-- <b> -->x<?xml?>",
+			want: []token{
+				{htmltoken.Other, "<!DOCTYPE html>", ""},
+				{htmltoken.Other, "<!-- <b> -->", ""},
+				{htmltoken.Text, "x", ""},
+				{htmltoken.Other, "<?xml?>", ""},
+			},
+		},
+		{
+			name:  "raw text with runes that change length when lower cased",
+			given: "<script>" + strings.Repeat("Ⱥİ", 10) + "</SCRIPT>after",
+			want: []token{
+				{htmltoken.StartTag, "<script>", "script"},
+				{htmltoken.RawText, strings.Repeat("Ⱥİ", 10), ""},
+				{htmltoken.EndTag, "</SCRIPT>", "script"},
+				{htmltoken.Text, "after", ""},
+			},
+		},
+		{
+			name:  "unterminated",
+			given: "<style>b{}",
+			want: []token{
+				{htmltoken.StartTag, "<style>", "style"},
+				{htmltoken.RawText, "b{}", ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []token
+			for _, tok := range htmltoken.Tokenize(tt.given) {
+				got = append(got, token{tok.Kind, tt.given[tok.Start:tok.End], tok.Name})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\ngot      %#v\nbut want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package dom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JSONVersion is the version of the JSON encoding of Node.
+//
+// A node is encoded as an object with the keys
+//
+//	"v": JSONVersion, on the root node only
+//	"t": Name
+//	"a": Attributes
+//	"c": Children
+//	"x": InnerText
+//	"h": InnerHTML
+//	"k": Component
+//
+// and an attribute as an object with the keys "n" for Name, and "x" for ValueText
+// or "h" for ValueHTML. Empty values are left out. At most one of "c", "x" and "h"
+// is set on a node, and at most one of "x" and "h" on an attribute.
+const JSONVersion = 1
+
+// ErrUnsafeJSON is wrapped by the errors of decoding JSON with content that is not
+// allowed by JSONOptions.
+var ErrUnsafeJSON = errors.New("unsafe content")
+
+// JSONOptions configures DecodeJSON. The zero value only allows content that is
+// safe to render from untrusted JSON.
+type JSONOptions struct {
+	// AllowHTML allows InnerHTML, ValueHTML, `<script>` and other active elements,
+	// `on*` event handler attributes and `javascript:` URLs, i.e. JSON that is
+	// trusted as much as the html it renders.
+	AllowHTML bool
+}
+
+type jsonNode struct {
+	Version    int             `json:"v,omitempty"`
+	Name       string          `json:"t,omitempty"`
+	Attributes []jsonAttribute `json:"a,omitempty"`
+	Children   []jsonNode      `json:"c,omitempty"`
+	InnerText  string          `json:"x,omitempty"`
+	InnerHTML  string          `json:"h,omitempty"`
+	Component  string          `json:"k,omitempty"`
+}
+
+type jsonAttribute struct {
+	Name      string `json:"n"`
+	ValueText string `json:"x,omitempty"`
+	ValueHTML string `json:"h,omitempty"`
+}
+
+// MarshalJSON returns the JSON encoding of the node, see JSONVersion.
+func (e Node) MarshalJSON() ([]byte, error) {
+	wire := toJSONNode(e)
+	wire.Version = JSONVersion
+	return json.Marshal(wire)
+}
+
+// UnmarshalJSON decodes a node encoded by MarshalJSON, rejecting content that is
+// unsafe to render from untrusted JSON. Use DecodeJSON to allow it.
+func (e *Node) UnmarshalJSON(data []byte) error {
+	n, err := DecodeJSON(data, JSONOptions{})
+	if err != nil {
+		return err
+	}
+	*e = n
+	return nil
+}
+
+// DecodeJSON decodes a node encoded by Node.MarshalJSON, and checks it against opts.
+func DecodeJSON(data []byte, opts JSONOptions) (Node, error) {
+	var wire jsonNode
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&wire); err != nil {
+		return Node{}, fmt.Errorf("dom: %w", err)
+	}
+	if wire.Version != JSONVersion {
+		return Node{}, fmt.Errorf("dom: unsupported json version %d", wire.Version)
+	}
+	return fromJSONNode(wire, opts, "root")
+}
+
+// MarshalJSON returns the JSON encoding of the attribute, see JSONVersion.
+func (a Attribute) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonAttribute{Name: a.Name, ValueText: a.ValueText, ValueHTML: string(a.ValueHTML)})
+}
+
+// UnmarshalJSON decodes an attribute encoded by MarshalJSON, rejecting values that
+// are unsafe to render from untrusted JSON.
+func (a *Attribute) UnmarshalJSON(data []byte) error {
+	var wire jsonAttribute
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&wire); err != nil {
+		return fmt.Errorf("dom: %w", err)
+	}
+	attr, err := fromJSONAttribute(wire, "", JSONOptions{}, "attribute")
+	if err != nil {
+		return err
+	}
+	*a = attr
+	return nil
+}
+
+func toJSONNode(e Node) jsonNode {
+	wire := jsonNode{
+		Name:      e.Name,
+		InnerText: e.InnerText,
+		InnerHTML: string(e.InnerHTML),
+		Component: e.Component,
+	}
+	for _, attr := range e.Attributes {
+		wire.Attributes = append(wire.Attributes, jsonAttribute{Name: attr.Name, ValueText: attr.ValueText, ValueHTML: string(attr.ValueHTML)})
+	}
+	// like buildHTML, InnerHTML wins over InnerText, which wins over Children
+	switch {
+	case wire.InnerHTML != "":
+		wire.InnerText = ""
+	case wire.InnerText == "":
+		for _, child := range e.Children {
+			wire.Children = append(wire.Children, toJSONNode(child))
+		}
+	}
+	return wire
+}
+
+func fromJSONNode(wire jsonNode, opts JSONOptions, path string) (Node, error) {
+	if wire.Version != 0 && path != "root" {
+		return Node{}, fmt.Errorf("dom: %s: version is only allowed on the root node", path)
+	}
+	if wire.Name != "" && !validName.MatchString(wire.Name) {
+		return Node{}, fmt.Errorf("dom: %s: invalid element name %q", path, wire.Name)
+	}
+	contents := 0
+	for _, set := range []bool{len(wire.Children) > 0, wire.InnerText != "", wire.InnerHTML != ""} {
+		if set {
+			contents++
+		}
+	}
+	if contents > 1 {
+		return Node{}, fmt.Errorf("dom: %s: only one of children, text and html is allowed", path)
+	}
+	if !opts.AllowHTML {
+		if wire.InnerHTML != "" {
+			return Node{}, fmt.Errorf("dom: %s: html: %w", path, ErrUnsafeJSON)
+		}
+		if unsafeElements[strings.ToLower(wire.Name)] {
+			return Node{}, fmt.Errorf("dom: %s: <%s>: %w", path, wire.Name, ErrUnsafeJSON)
+		}
+	}
+
+	e := Node{
+		Name:      wire.Name,
+		InnerText: wire.InnerText,
+		InnerHTML: template.HTML(wire.InnerHTML),
+		Component: wire.Component,
+	}
+	for i, attr := range wire.Attributes {
+		a, err := fromJSONAttribute(attr, wire.Name, opts, path+".attributes["+strconv.Itoa(i)+"]")
+		if err != nil {
+			return Node{}, err
+		}
+		e.Attributes = append(e.Attributes, a)
+	}
+	for i, child := range wire.Children {
+		c, err := fromJSONNode(child, opts, path+".children["+strconv.Itoa(i)+"]")
+		if err != nil {
+			return Node{}, err
+		}
+		e.Children = append(e.Children, c)
+	}
+	return e, nil
+}
+
+func fromJSONAttribute(wire jsonAttribute, element string, opts JSONOptions, path string) (Attribute, error) {
+	if !validAttrName.MatchString(wire.Name) {
+		return Attribute{}, fmt.Errorf("dom: %s: invalid attribute name %q", path, wire.Name)
+	}
+	if wire.ValueText != "" && wire.ValueHTML != "" {
+		return Attribute{}, fmt.Errorf("dom: %s: only one of text and html is allowed", path)
+	}
+	if !opts.AllowHTML {
+		name := strings.ToLower(wire.Name)
+		switch {
+		case wire.ValueHTML != "":
+			return Attribute{}, fmt.Errorf("dom: %s: html: %w", path, ErrUnsafeJSON)
+		case strings.HasPrefix(name, "on") || name == "srcdoc":
+			return Attribute{}, fmt.Errorf("dom: %s: %s: %w", path, wire.Name, ErrUnsafeJSON)
+		case urlAttributes[name] && !safeJSONURL(wire.ValueText, strings.EqualFold(element, "img") && name == "src"):
+			return Attribute{}, fmt.Errorf("dom: %s: %s=%q: %w", path, wire.Name, wire.ValueText, ErrUnsafeJSON)
+		}
+	}
+	return Attribute{Name: wire.Name, ValueText: wire.ValueText, ValueHTML: template.HTMLAttr(wire.ValueHTML)}, nil
+}
+
+var (
+	validName     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*(?::[A-Za-z][A-Za-z0-9-]*)?$`)
+	validAttrName = regexp.MustCompile(`^[^\s"'<>/=\x00-\x1f\x7f]+$`)
+
+	unsafeURLScheme = regexp.MustCompile(`(?i)^(?:javascript|vbscript|data):`)
+	safeImageData   = regexp.MustCompile(`(?i)^data:image/(?:png|gif|jpeg|webp)[;,]`)
+)
+
+// unsafeElements can run scripts, or change how the rest of the page loads. The SVG
+// animation elements can set any attribute of their parent, e.g. an href to a
+// `javascript:` URL, or an event handler. Names are lowercase.
+var unsafeElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+	"object": true, "embed": true, "applet": true, "base": true, "meta": true,
+	"link": true, "template": true,
+	"animate": true, "set": true, "animatemotion": true, "animatetransform": true, "handler": true,
+}
+
+// urlAttributes have URLs as values.
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "action": true, "formaction": true, "poster": true,
+	"cite": true, "data": true, "background": true, "xlink:href": true,
+}
+
+// safeJSONURL reports whether url cannot run scripts. Browsers ignore whitespace
+// and control characters in the scheme, so they are ignored here too.
+func safeJSONURL(url string, image bool) bool {
+	url = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, url)
+	return !unsafeURLScheme.MatchString(url) || (image && safeImageData.MatchString(url))
+}
@@ -0,0 +1,193 @@
+package dom_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+)
+
+func TestNodeMarshalJSON(t *testing.T) {
+	given := dom.Component("card", dom.Div(dom.Attrs("class", "card"),
+		dom.H2(dom.Attrs(), dom.InnerText("Title & more")),
+		dom.P(dom.Attrs(), dom.InnerHTML("<b>trusted</b>")),
+		dom.Node{Name: "a", Attributes: []dom.Attribute{{Name: "href", ValueHTML: "/x?a=1&amp;b=2"}}, Children: []dom.Node{dom.InnerText("link")}},
+	))
+
+	got, err := json.Marshal(given)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"v":1,"t":"div","a":[{"n":"class","x":"card"}],"c":[` +
+		`{"t":"h2","c":[{"x":"Title \u0026 more"}]},` +
+		`{"t":"p","c":[{"h":"\u003cb\u003etrusted\u003c/b\u003e"}]},` +
+		`{"t":"a","a":[{"n":"href","h":"/x?a=1\u0026amp;b=2"}],"c":[{"x":"link"}]}` +
+		`],"k":"card"}`
+	if string(got) != want {
+		t.Errorf("\ngot      %s\nbut want %s", got, want)
+	}
+
+	decoded, err := dom.DecodeJSON(got, dom.JSONOptions{AllowHTML: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.HTML() != given.HTML() || decoded.Component != "card" {
+		t.Errorf("\ngot      %q\nbut want %q", decoded.HTML(), given.HTML())
+	}
+
+	var untrusted dom.Node
+	if err := json.Unmarshal(got, &untrusted); !errors.Is(err, dom.ErrUnsafeJSON) {
+		t.Errorf("got %v but want ErrUnsafeJSON", err)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   string
+		opts    dom.JSONOptions
+		want    string
+		wantErr string
+		unsafe  bool
+	}{
+		{
+			name:  "text",
+			given: `{"v":1,"t":"p","a":[{"n":"title","x":"<hi>"}],"c":[{"x":"a < b"},{"t":"img","a":[{"n":"src","x":"data:image/png;base64,AA"}]}]}`,
+			want:  `<p title="&lt;hi&gt;">a &lt; b<img src="data:image/png;base64,AA"/></p>`,
+		},
+		{
+			name:    "missing version",
+			given:   `{"t":"p"}`,
+			wantErr: "dom: unsupported json version 0",
+		},
+		{
+			name:    "version on a child",
+			given:   `{"v":1,"c":[{"v":1}]}`,
+			wantErr: "dom: root.children[0]: version is only allowed on the root node",
+		},
+		{
+			name:    "unknown key",
+			given:   `{"v":1,"z":1}`,
+			wantErr: `dom: json: unknown field "z"`,
+		},
+		{
+			name:    "more than one content",
+			given:   `{"v":1,"t":"p","x":"a","c":[{"x":"b"}]}`,
+			wantErr: "dom: root: only one of children, text and html is allowed",
+		},
+		{
+			name:    "invalid element name",
+			given:   `{"v":1,"t":"img src=x onerror=alert(1)"}`,
+			wantErr: `dom: root: invalid element name "img src=x onerror=alert(1)"`,
+		},
+		{
+			name:    "invalid attribute name",
+			given:   `{"v":1,"t":"p","a":[{"n":"a\"b"}]}`,
+			wantErr: `dom: root.attributes[0]: invalid attribute name "a\"b"`,
+		},
+		{
+			name:    "inner html",
+			given:   `{"v":1,"t":"p","c":[{"h":"<b>x</b>"}]}`,
+			wantErr: "dom: root.children[0]: html: unsafe content",
+			unsafe:  true,
+		},
+		{
+			name:    "attribute html",
+			given:   `{"v":1,"t":"p","a":[{"n":"title","h":"x"}]}`,
+			wantErr: "dom: root.attributes[0]: html: unsafe content",
+			unsafe:  true,
+		},
+		{
+			name:    "script",
+			given:   `{"v":1,"c":[{"t":"SCRIPT","x":"alert(1)"}]}`,
+			wantErr: "dom: root.children[0]: <SCRIPT>: unsafe content",
+			unsafe:  true,
+		},
+		{
+			name:    "event handler",
+			given:   `{"v":1,"t":"img","a":[{"n":"onError","x":"alert(1)"}]}`,
+			wantErr: "dom: root.attributes[0]: onError: unsafe content",
+			unsafe:  true,
+		},
+		{
+			name:    "javascript url",
+			given:   `{"v":1,"t":"a","a":[{"n":"href","x":" java\tscript:alert(1)"}]}`,
+			wantErr: `dom: root.attributes[0]: href=" java\tscript:alert(1)": unsafe content`,
+			unsafe:  true,
+		},
+		{
+			name:    "data url outside of images",
+			given:   `{"v":1,"t":"a","a":[{"n":"href","x":"data:image/png;base64,AA"}]}`,
+			wantErr: `dom: root.attributes[0]: href="data:image/png;base64,AA": unsafe content`,
+			unsafe:  true,
+		},
+		{
+			name:    "svg animate",
+			given:   `{"v":1,"t":"svg","c":[{"t":"a","c":[{"t":"animate","a":[{"n":"attributeName","x":"href"},{"n":"values","x":"javascript:alert(1)"}]},{"t":"text","c":[{"x":"click"}]}]}]}`,
+			wantErr: "dom: root.children[0].children[0]: <animate>: unsafe content",
+			unsafe:  true,
+		},
+		{
+			name:    "svg set",
+			given:   `{"v":1,"t":"svg","c":[{"t":"set","a":[{"n":"attributeName","x":"onmouseover"},{"n":"to","x":"alert(1)"}]}]}`,
+			wantErr: "dom: root.children[0]: <set>: unsafe content",
+			unsafe:  true,
+		},
+		{
+			name:    "svg animateMotion",
+			given:   `{"v":1,"t":"svg","c":[{"t":"animateMotion"}]}`,
+			wantErr: "dom: root.children[0]: <animateMotion>: unsafe content",
+			unsafe:  true,
+		},
+		{
+			name:  "allowed html",
+			given: `{"v":1,"c":[{"t":"script","x":"go()"},{"t":"a","a":[{"n":"href","x":"javascript:go()"},{"n":"onclick","h":"go()"}],"c":[{"h":"<b>go</b>"}]}]}`,
+			opts:  dom.JSONOptions{AllowHTML: true},
+			want:  `<script>go()</script><a href="javascript:go()" onclick="go()"><b>go</b></a>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dom.DecodeJSON([]byte(tt.given), tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("\ngot      %v\nbut want %s", err, tt.wantErr)
+				}
+				if errors.Is(err, dom.ErrUnsafeJSON) != tt.unsafe {
+					t.Errorf("got errors.Is(err, ErrUnsafeJSON) %v", !tt.unsafe)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got.HTML()) != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got.HTML(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributeJSON(t *testing.T) {
+	given := dom.Attribute{Name: "title", ValueText: "a \"b\""}
+	data, err := json.Marshal(given)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"n":"title","x":"a \"b\""}`; string(data) != want {
+		t.Errorf("\ngot      %s\nbut want %s", data, want)
+	}
+
+	var got dom.Attribute
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != given {
+		t.Errorf("\ngot      %#v\nbut want %#v", got, given)
+	}
+
+	if err := json.Unmarshal([]byte(`{"n":"onclick","x":"go()"}`), &got); !errors.Is(err, dom.ErrUnsafeJSON) {
+		t.Errorf("got %v but want ErrUnsafeJSON", err)
+	}
+}
@@ -0,0 +1,353 @@
+// Package live serves views that update in the browser as their state changes,
+// without JavaScript written for each page.
+//
+// The server holds the state of each page view, and renders it with View.Render.
+// Elements with a `live-click`, `live-input` or `live-submit` attribute send those
+// events back to View.Handle, and the changes to the rendered elements are pushed
+// to the page as patches over Server-Sent Events, where a small bundled script
+// applies them.
+//
+// Example:
+//
+//	http.Handle("/counter", &live.View[int]{
+//		Mount: func(r *http.Request) (int, error) { return 0, nil },
+//		Render: func(count int) dom.Node {
+//			return dom.Button(dom.Attrs("live-click", "inc"), dom.InnerText(strconv.Itoa(count)))
+//		},
+//		Handle: func(count int, ev live.Event) (int, error) { return count + 1, nil },
+//	})
+//
+// Patches address nodes by their position, so Render should return html that
+// browsers parse into the same tree, e.g. tables with a `<tbody>`.
+package live
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+	"github.com/choonkeat/dom-go/sse"
+)
+
+// Attributes that send the events of an element to View.Handle, with the value of
+// the attribute as Event.Name.
+const (
+	ClickAttr  = "live-click"
+	InputAttr  = "live-input"
+	SubmitAttr = "live-submit"
+)
+
+// SessionAttr is the attribute of the element around a live view, naming its session.
+const SessionAttr = "data-live-session"
+
+// DefaultConnectTimeout is how long a page view is kept while its script is not
+// connected, before it first connects or after it disconnects.
+const DefaultConnectTimeout = time.Minute
+
+// maxEventSize is the size of the largest event that a page can post.
+const maxEventSize = 1 << 20
+
+//go:embed live.js
+var script string
+
+// Script is the JavaScript that runs live views in the browser. View writes it in
+// every page; it is exported for pages with a Content-Security-Policy that needs
+// its hash.
+func Script() string {
+	return script
+}
+
+// Event is an event of an element in the page.
+type Event struct {
+	// Type is "click", "input" or "submit".
+	Type string `json:"type"`
+
+	// Name is the value of the ClickAttr, InputAttr or SubmitAttr of the element.
+	Name string `json:"name"`
+
+	// Value is the value of the element, for "input" events.
+	Value string `json:"value,omitempty"`
+
+	// Form is the fields of the form, for "submit" events.
+	Form url.Values `json:"form,omitempty"`
+}
+
+// View is an http.Handler serving a live view of a state of type S. Mount, Render
+// and Handle are required.
+//
+// A GET request is a new page view: its state is mounted and the page written in
+// full. The script in the page then connects to the same URL for the patches, and
+// posts events to it. If its connection drops, the script reconnects to the same
+// page view, unless it has been idle for longer than ConnectTimeout.
+type View[S any] struct {
+	// Mount returns the state of a new page view.
+	Mount func(r *http.Request) (S, error)
+
+	// Render returns the elements of a state.
+	Render func(state S) dom.Node
+
+	// Handle returns the state after an event in the page.
+	Handle func(state S, ev Event) (S, error)
+
+	// Run, if set, is called in a goroutine of its own while a page is connected,
+	// to change its state from the server, e.g. on a timer. Each call to update
+	// replaces the state with the result of fn and patches the page. ctx is done
+	// when the page is closed.
+	Run func(ctx context.Context, update func(fn func(S) S))
+
+	// Layout, if set, returns the page around live, i.e. the live view and its
+	// script, e.g. with a title and stylesheets. By default it is a bare html
+	// document.
+	Layout func(live dom.Node) dom.Node
+
+	// ConnectTimeout is how long a page view is kept while its script is not
+	// connected, before it first connects or after it disconnects, e.g. for the
+	// network to come back; zero is DefaultConnectTimeout.
+	ConnectTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*session[S]
+}
+
+// session is the state of a page view.
+type session[S any] struct {
+	mu        sync.Mutex
+	state     S
+	tree      dom.Node // what the page shows, once the pending patches are applied
+	pending   []patchJSON
+	notify    chan struct{} // has a value when there are pending patches
+	connected bool
+	streams   int                // streams connected so far; the last one is current
+	cancel    context.CancelFunc // ends the current stream
+	idle      int                // times the page view became idle, i.e. not connected
+}
+
+// ServeHTTP serves the page of a new page view, or the patches and events of one
+// with the `live` and `session` query parameters.
+func (v *View[S]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch live := query.Get("live"); {
+	case live == "" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		v.servePage(w, r)
+	case live == "stream" && r.Method == http.MethodGet:
+		v.serveStream(w, r, query.Get("session"))
+	case live == "event" && r.Method == http.MethodPost:
+		v.serveEvent(w, r, query.Get("session"))
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (v *View[S]) servePage(w http.ResponseWriter, r *http.Request) {
+	state, err := v.Mount(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodHead {
+		return // no page view, since no script will connect to it
+	}
+	id, err := newSessionID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s := &session[S]{state: state, notify: make(chan struct{}, 1)}
+	s.tree = v.render(id, state)
+
+	v.mu.Lock()
+	if v.sessions == nil {
+		v.sessions = map[string]*session[S]{}
+	}
+	v.sessions[id] = s
+	v.mu.Unlock()
+
+	s.mu.Lock()
+	v.removeWhenIdle(id, s)
+	s.mu.Unlock()
+
+	body := dom.Node{Children: []dom.Node{
+		s.tree,
+		dom.Script(dom.Attrs(), dom.InnerHTML(script)),
+	}}
+	page := dom.Html(dom.Attrs(), dom.Head(dom.Attrs(), dom.Meta(dom.Attrs("charset", "utf-8"))), dom.Body(dom.Attrs(), body))
+	if v.Layout != nil {
+		page = v.Layout(body)
+	}
+	fmt.Fprintf(w, "<!DOCTYPE html>%s", page.HTML())
+}
+
+func (v *View[S]) serveStream(w http.ResponseWriter, r *http.Request, id string) {
+	s := v.session(id)
+	if s == nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	s.mu.Lock()
+	if s.cancel != nil {
+		// the page reconnected before its last stream was seen to drop
+		s.cancel()
+	}
+	s.streams++
+	current := s.streams
+	s.connected, s.cancel = true, cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.streams == current {
+			s.connected, s.cancel = false, nil
+			v.removeWhenIdle(id, s)
+		}
+	}()
+
+	stream, err := sse.NewWriter(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if v.Run != nil {
+		go v.Run(ctx, func(fn func(S) S) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			v.update(id, s, fn(s.state))
+		})
+	}
+
+	if err := stream.Comment("connected"); err != nil {
+		return
+	}
+	for {
+		s.mu.Lock()
+		if s.streams != current {
+			s.mu.Unlock()
+			return // leave the pending patches to the stream that took over
+		}
+		pending := s.pending
+		s.pending = nil
+		s.mu.Unlock()
+		if len(pending) > 0 {
+			var data bytes.Buffer
+			enc := json.NewEncoder(&data)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(pending); err != nil {
+				return
+			}
+			patches := strings.TrimSuffix(data.String(), "\n")
+			if err := stream.Send(sse.Event{Name: "patch", Data: dom.InnerHTML(patches)}); err != nil {
+				return
+			}
+		}
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (v *View[S]) serveEvent(w http.ResponseWriter, r *http.Request, id string) {
+	s := v.session(id)
+	if s == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var ev Event
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEventSize)).Decode(&ev); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := v.Handle(s.state, ev)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	v.update(id, s, state)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// update sets the state of s, and queues the patches to the page. s.mu is held.
+func (v *View[S]) update(id string, s *session[S], state S) {
+	s.state = state
+	tree := v.render(id, state)
+	patches := domutil.Diff(s.tree, tree)
+	s.tree = tree
+	if len(patches) == 0 {
+		return
+	}
+	for _, patch := range patches {
+		s.pending = append(s.pending, toPatchJSON(patch))
+	}
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// render returns the live view of state, in the element that the script finds it by.
+func (v *View[S]) render(id string, state S) dom.Node {
+	return normalize(dom.Div(dom.Attrs(SessionAttr, id), v.Render(state)))
+}
+
+func (v *View[S]) session(id string) *session[S] {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.sessions[id]
+}
+
+// removeWhenIdle removes the session id after ConnectTimeout, unless it has been
+// connected since. s.mu is held.
+func (v *View[S]) removeWhenIdle(id string, s *session[S]) {
+	timeout := v.ConnectTimeout
+	if timeout <= 0 {
+		timeout = DefaultConnectTimeout
+	}
+	s.idle++
+	idle := s.idle
+	time.AfterFunc(timeout, func() {
+		s.mu.Lock()
+		stillIdle := !s.connected && s.idle == idle
+		s.mu.Unlock()
+		if stillIdle {
+			v.removeSession(id)
+		}
+	})
+}
+
+func (v *View[S]) removeSession(id string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.sessions, id)
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
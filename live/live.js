@@ -0,0 +1,122 @@
+// Client of the live package: applies the patches the server sends over
+// Server-Sent Events, and posts the events of elements with live-* attributes.
+(function () {
+  "use strict";
+
+  function nodeAt(root, path) {
+    var node = root;
+    for (var i = 0; i < path.length; i++) {
+      node = node.childNodes[path[i]];
+    }
+    return node;
+  }
+
+  function parse(patch) {
+    if (patch.text !== undefined) {
+      return document.createTextNode(patch.text);
+    }
+    var template = document.createElement("template");
+    template.innerHTML = patch.html;
+    return template.content.firstChild;
+  }
+
+  function apply(root, patch) {
+    var node = nodeAt(root, patch.path);
+    switch (patch.op) {
+      case "insert":
+        node.insertBefore(parse(patch), node.childNodes[patch.index] || null);
+        break;
+      case "remove":
+        node.removeChild(node.childNodes[patch.index]);
+        break;
+      case "move":
+        var child = node.childNodes[patch.from];
+        node.removeChild(child);
+        node.insertBefore(child, node.childNodes[patch.index] || null);
+        break;
+      case "setAttr":
+        var value = patch.value || "";
+        node.setAttribute(patch.name, value);
+        if (patch.name === "value" && node.value !== value) {
+          node.value = value;
+        } else if (patch.name === "checked") {
+          node.checked = true;
+        }
+        break;
+      case "removeAttr":
+        node.removeAttribute(patch.name);
+        if (patch.name === "checked") {
+          node.checked = false;
+        }
+        break;
+      case "text":
+        if (node.nodeType === Node.TEXT_NODE) {
+          node.data = patch.text;
+        } else {
+          node.textContent = patch.text;
+        }
+        break;
+      case "html":
+        node.innerHTML = patch.html;
+        break;
+      case "replace":
+        node.parentNode.replaceChild(parse(patch), node);
+        break;
+    }
+  }
+
+  function start(root) {
+    if (root.liveStarted) {
+      return;
+    }
+    root.liveStarted = true;
+    var url = location.pathname + "?session=" + encodeURIComponent(root.getAttribute("data-live-session")) + "&live=";
+
+    function send(event) {
+      fetch(url + "event", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(event),
+      });
+    }
+
+    function on(type, attr, event) {
+      root.addEventListener(type, function (e) {
+        var el = e.target.closest && e.target.closest("[" + attr + "]");
+        if (el && root.contains(el)) {
+          if (type !== "input") {
+            e.preventDefault();
+          }
+          send(event(el, el.getAttribute(attr)));
+        }
+      });
+    }
+    on("click", "live-click", function (el, name) {
+      return { type: "click", name: name };
+    });
+    on("input", "live-input", function (el, name) {
+      return { type: "input", name: name, value: el.value };
+    });
+    on("submit", "live-submit", function (el, name) {
+      var form = {};
+      new FormData(el).forEach(function (value, key) {
+        (form[key] = form[key] || []).push(String(value));
+      });
+      return { type: "submit", name: name, form: form };
+    });
+
+    var source = new EventSource(url + "stream");
+    source.addEventListener("patch", function (e) {
+      JSON.parse(e.data).forEach(function (patch) {
+        apply(root, patch);
+      });
+    });
+    source.onerror = function () {
+      if (source.readyState === EventSource.CLOSED) {
+        location.reload(); // the session is gone, start a new one
+      }
+    };
+  }
+
+  document.querySelectorAll("[data-live-session]").forEach(start);
+})();
@@ -0,0 +1,263 @@
+package live_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/live"
+)
+
+// counter is a view of a count, with a button to add to it.
+func counter() *live.View[int] {
+	return &live.View[int]{
+		Mount: func(r *http.Request) (int, error) {
+			return strconv.Atoi(r.URL.Query().Get("start"))
+		},
+		Render: func(count int) dom.Node {
+			return dom.Button(dom.Attrs("live-click", "inc"), dom.InnerText(strconv.Itoa(count)))
+		},
+		Handle: func(count int, ev live.Event) (int, error) {
+			if ev.Type != "click" || ev.Name != "inc" {
+				return count, errors.New("unknown event")
+			}
+			return count + 1, nil
+		},
+	}
+}
+
+var sessionPattern = regexp.MustCompile(`data-live-session="([0-9a-f]+)"`)
+
+// mount gets the page of a new page view, and returns its session.
+func mount(t *testing.T, url string) (string, string) {
+	t.Helper()
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var sb strings.Builder
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		sb.WriteString(scanner.Text())
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d: %s", res.StatusCode, sb.String())
+	}
+	m := sessionPattern.FindStringSubmatch(sb.String())
+	if m == nil {
+		t.Fatalf("no session in %q", sb.String())
+	}
+	return sb.String(), m[1]
+}
+
+// stream connects to the patches of a session, and returns the data of its events.
+func stream(t *testing.T, ctx context.Context, url, session string) <-chan string {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"?live=stream&session="+session, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("got status %d, %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	events := make(chan string)
+	go func() {
+		defer res.Body.Close()
+		defer close(events)
+		scanner := bufio.NewScanner(res.Body)
+		name := ""
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				events <- name + " " + strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+func post(t *testing.T, url, session, body string) int {
+	t.Helper()
+	res, err := http.Post(url+"?live=event&session="+session, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res.StatusCode
+}
+
+func receive(t *testing.T, events <-chan string) string {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	return ""
+}
+
+func TestView(t *testing.T) {
+	view := counter()
+	view.ConnectTimeout = 200 * time.Millisecond
+	server := httptest.NewServer(view)
+	defer server.Close()
+
+	page, session := mount(t, server.URL+"?start=41")
+	want := `<div data-live-session="` + session + `"><button live-click="inc">41</button></div><script>`
+	if !strings.HasPrefix(page, `<!DOCTYPE html><html><head><meta charset="utf-8"/></head><body>`) || !strings.Contains(page, want) {
+		t.Errorf("\ngot      %q\nbut want %q", page, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := stream(t, ctx, server.URL, session)
+
+	if got := post(t, server.URL, session, `{"type":"click","name":"inc"}`); got != http.StatusNoContent {
+		t.Errorf("got status %d", got)
+	}
+	if got, want := receive(t, events), `patch [{"op":"text","path":[0,0],"text":"42"}]`; got != want {
+		t.Errorf("\ngot      %s\nbut want %s", got, want)
+	}
+
+	if got := post(t, server.URL, session, `{"type":"click","name":"dec"}`); got != http.StatusInternalServerError {
+		t.Errorf("got status %d", got)
+	}
+	if got := post(t, server.URL, session, `not json`); got != http.StatusBadRequest {
+		t.Errorf("got status %d", got)
+	}
+	if got := post(t, server.URL, session, `{"type":"input","name":"`+strings.Repeat("x", 2<<20)+`"}`); got != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d", got)
+	}
+
+	// the page reconnects after its stream drops, and keeps its state
+	cancel()
+	<-events
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	events = stream(t, ctx, server.URL, session)
+	if got := post(t, server.URL, session, `{"type":"click","name":"inc"}`); got != http.StatusNoContent {
+		t.Errorf("got status %d", got)
+	}
+	if got, want := receive(t, events), `patch [{"op":"text","path":[0,0],"text":"43"}]`; got != want {
+		t.Errorf("\ngot      %s\nbut want %s", got, want)
+	}
+
+	// the session ends when it stays disconnected for ConnectTimeout
+	cancel()
+	for i := 0; post(t, server.URL, session, `{"type":"click","name":"inc"}`) != http.StatusNotFound; i++ {
+		if i == 100 {
+			t.Fatal("session was not removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestViewHead(t *testing.T) {
+	view := counter()
+	view.Layout = func(live dom.Node) dom.Node {
+		t.Error("HEAD started a page view")
+		return live
+	}
+	server := httptest.NewServer(view)
+	defer server.Close()
+
+	res, err := http.Head(server.URL + "?start=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("got status %d, %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+}
+
+func TestViewErrors(t *testing.T) {
+	server := httptest.NewServer(counter())
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		want   int
+	}{
+		{name: "mount error", method: http.MethodGet, query: "?start=x", want: http.StatusInternalServerError},
+		{name: "unknown session", method: http.MethodGet, query: "?live=stream&session=x", want: http.StatusNotFound},
+		{name: "unknown session event", method: http.MethodPost, query: "?live=event&session=x", want: http.StatusNotFound},
+		{name: "post page", method: http.MethodPost, query: "?start=1", want: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.query, strings.NewReader("{}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tt.want {
+				t.Errorf("got status %d but want %d", res.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewRun(t *testing.T) {
+	view := counter()
+	view.Run = func(ctx context.Context, update func(func(int) int)) {
+		update(func(count int) int { return count * 10 })
+		<-ctx.Done()
+	}
+	view.Layout = func(live dom.Node) dom.Node {
+		return dom.Html(dom.Attrs(), dom.Body(dom.Attrs(), dom.H1(dom.Attrs(), dom.InnerText("Counter")), live))
+	}
+	server := httptest.NewServer(view)
+	defer server.Close()
+
+	page, session := mount(t, server.URL+"?start=5")
+	if !strings.HasPrefix(page, `<!DOCTYPE html><html><body><h1>Counter</h1><div data-live-session=`) {
+		t.Errorf("got %q", page)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := stream(t, ctx, server.URL, session)
+	if got, want := receive(t, events), `patch [{"op":"text","path":[0,0],"text":"50"}]`; got != want {
+		t.Errorf("\ngot      %s\nbut want %s", got, want)
+	}
+
+	// a reconnect takes over from a stream that has not been seen to drop
+	reconnected := stream(t, ctx, server.URL, session)
+	if _, ok := <-events; ok {
+		t.Error("the first stream was not ended")
+	}
+	if got, want := receive(t, reconnected), `patch [{"op":"text","path":[0,0],"text":"500"}]`; got != want {
+		t.Errorf("\ngot      %s\nbut want %s", got, want)
+	}
+}
+
+func TestScript(t *testing.T) {
+	if !strings.Contains(live.Script(), `EventSource(url + "stream")`) {
+		t.Errorf("got %q", live.Script())
+	}
+}
@@ -0,0 +1,113 @@
+package live
+
+import (
+	"html/template"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/domutil"
+)
+
+// normalize returns n with the same html, in the tree that browsers parse it into,
+// so that the paths of patches are the same in both: fragments are replaced by
+// their children, adjacent text is joined, and the children of an element with
+// raw html among them are written as its InnerHTML.
+func normalize(n dom.Node) dom.Node {
+	switch {
+	case n.InnerHTML != "":
+		n.InnerText = ""
+		n.Children = nil
+		return n
+	case n.InnerText != "":
+		n.Children = nil
+		return n
+	}
+
+	var children []dom.Node
+	raw := false
+	var add func(nodes []dom.Node)
+	add = func(nodes []dom.Node) {
+		for _, child := range nodes {
+			switch {
+			case child.Name == "" && child.InnerHTML != "":
+				raw = true
+				children = append(children, child)
+			case child.Name == "" && child.InnerText != "":
+				if last := len(children) - 1; last >= 0 && children[last].Name == "" && children[last].InnerHTML == "" {
+					children[last].InnerText += child.InnerText
+					continue
+				}
+				children = append(children, dom.InnerText(child.InnerText))
+			case child.Name == "":
+				add(child.Children)
+			default:
+				children = append(children, normalize(child))
+			}
+		}
+	}
+	add(n.Children)
+
+	if raw {
+		n.InnerHTML = template.HTML(dom.Node{Children: children}.HTML())
+		n.Children = nil
+		return n
+	}
+	n.Children = children
+	return n
+}
+
+// patchJSON is a domutil.Patch as the script applies it.
+type patchJSON struct {
+	Op    string  `json:"op"`
+	Path  []int   `json:"path"`
+	Index int     `json:"index,omitempty"`
+	From  int     `json:"from,omitempty"`
+	Name  string  `json:"name,omitempty"`
+	Value string  `json:"value,omitempty"`
+	Text  *string `json:"text,omitempty"`
+	HTML  string  `json:"html,omitempty"`
+}
+
+func toPatchJSON(patch domutil.Patch) patchJSON {
+	p := patchJSON{Path: patch.Path}
+	switch patch.Op {
+	case domutil.InsertChild:
+		p.Op = "insert"
+		p.Index = patch.Index
+		p.setNode(patch.Node)
+	case domutil.RemoveChild:
+		p.Op = "remove"
+		p.Index = patch.Index
+	case domutil.MoveChild:
+		p.Op = "move"
+		p.From = patch.From
+		p.Index = patch.Index
+	case domutil.SetAttr:
+		p.Op = "setAttr"
+		p.Name = patch.Attr.Name
+		p.Value, _ = dom.Node{Attributes: []dom.Attribute{patch.Attr}}.Attr(patch.Attr.Name)
+	case domutil.RemoveAttr:
+		p.Op = "removeAttr"
+		p.Name = patch.Attr.Name
+	case domutil.ReplaceText:
+		if patch.Node.InnerHTML != "" {
+			p.Op = "html"
+			p.HTML = string(patch.Node.InnerHTML)
+		} else {
+			p.Op = "text"
+			p.Text = &patch.Node.InnerText
+		}
+	case domutil.ReplaceNode:
+		p.Op = "replace"
+		p.setNode(patch.Node)
+	}
+	return p
+}
+
+// setNode sets the text of a text node, or else the html of an element.
+func (p *patchJSON) setNode(n dom.Node) {
+	if n.Name == "" && n.InnerHTML == "" {
+		p.Text = &n.InnerText
+		return
+	}
+	p.HTML = string(n.HTML())
+}
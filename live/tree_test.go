@@ -0,0 +1,70 @@
+package live_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/live"
+)
+
+// TestPatches checks the patches of a view that renders each state in turn.
+func TestPatches(t *testing.T) {
+	states := []dom.Node{
+		dom.Ul(dom.Attrs(),
+			dom.Node{Children: []dom.Node{
+				dom.InnerText("items: "),
+				dom.InnerText("2"),
+			}},
+			dom.Li(dom.Attrs("key", "a", "class", "x"), dom.InnerText("a")),
+			dom.Li(dom.Attrs("key", "b"), dom.InnerText("b")),
+		),
+		dom.Ul(dom.Attrs(),
+			dom.InnerText("items: 3"),
+			dom.Li(dom.Attrs("key", "b"), dom.InnerText("b")),
+			dom.Li(dom.Attrs("key", "a", "title", "A &amp; a"), dom.InnerText("a")),
+			dom.Li(dom.Attrs("key", "c"), dom.Em(dom.Attrs(), dom.InnerText("c"))),
+		),
+		dom.Ul(dom.Attrs(),
+			dom.InnerText("items: "),
+			dom.InnerHTML("<b>1</b>"),
+		),
+		dom.P(dom.Attrs(), dom.InnerText("done")),
+	}
+	view := &live.View[int]{
+		Mount:  func(r *http.Request) (int, error) { return 0, nil },
+		Render: func(i int) dom.Node { return states[i] },
+		Handle: func(i int, ev live.Event) (int, error) { return i + 1, nil },
+	}
+	server := httptest.NewServer(view)
+	defer server.Close()
+
+	page, session := mount(t, server.URL)
+	if want := `<ul>items: 2<li key="a" class="x">a</li>`; !strings.Contains(page, want) {
+		t.Errorf("\ngot      %q\nbut want %q", page, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := stream(t, ctx, server.URL, session)
+
+	wants := []string{
+		`patch [` +
+			`{"op":"insert","path":[0],"index":3,"html":"<li key=\"c\"><em>c</em></li>"},` +
+			`{"op":"move","path":[0],"index":1,"from":2},` +
+			`{"op":"text","path":[0,0],"text":"items: 3"},` +
+			`{"op":"removeAttr","path":[0,2],"name":"class"},` +
+			`{"op":"setAttr","path":[0,2],"name":"title","value":"A &amp; a"}]`,
+		`patch [{"op":"html","path":[0],"html":"items: <b>1</b>"}]`,
+		`patch [{"op":"replace","path":[0],"html":"<p>done</p>"}]`,
+	}
+	for _, want := range wants {
+		post(t, server.URL, session, `{"type":"click"}`)
+		if got := receive(t, events); got != want {
+			t.Errorf("\ngot      %s\nbut want %s", got, want)
+		}
+	}
+}
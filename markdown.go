@@ -0,0 +1,554 @@
+package dom
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Markdown returns a CommonMark representation of the node, with GitHub Flavored
+// Markdown tables and strikethrough, e.g. to publish the same content as html and
+// as Markdown.
+//
+// Elements with no Markdown equivalent, e.g. `<sup>` or `<details>`, and tables
+// that GitHub Flavored Markdown cannot represent, are written as raw html.
+// Containers like `<div>` and `<span>` are left out, keeping their content, and
+// `<script>` and `<style>` are left out altogether.
+func (e Node) Markdown() string {
+	ml := &mdLayout{}
+	return strings.Join(ml.lines([]Node{e}), "\n")
+}
+
+// hardBreak marks a `<br>` in inline content until it is written as a backslash at
+// the end of a line.
+const hardBreak = "\x00"
+
+// mdLayout renders nodes into lines of Markdown.
+type mdLayout struct {
+	listDepth int // lists inside list items follow their text without a blank line
+}
+
+// lines returns nodes as lines of Markdown.
+func (ml *mdLayout) lines(nodes []Node) []string {
+	b := &mdBuilder{}
+	for _, n := range nodes {
+		ml.add(b, n)
+	}
+	return b.finish()
+}
+
+// contentLines returns the content of n as lines of Markdown.
+func (ml *mdLayout) contentLines(n Node) []string {
+	b := &mdBuilder{}
+	ml.addContent(b, n)
+	return b.finish()
+}
+
+// inline returns the content of n as inline Markdown, with whitespace collapsed.
+func (ml *mdLayout) inline(n Node) string {
+	b := &mdBuilder{}
+	ml.addContent(b, n)
+	if len(b.out) > 0 {
+		// block content inside inline content; keep its text at least
+		b.flush()
+		return strings.Join(b.out, " ")
+	}
+	return collapseSpace(b.inline.String())
+}
+
+func (ml *mdLayout) add(b *mdBuilder, n Node) {
+	name := strings.ToLower(n.Name)
+	switch name {
+	case "":
+		ml.addContent(b, n)
+
+	case "script", "style", "template", "head":
+
+	case "br":
+		b.inline.WriteString(hardBreak)
+
+	case "hr":
+		b.block([]string{"---"}, false)
+
+	case "span", "label", "time", "data", "font":
+		ml.addContent(b, n)
+
+	case "strong", "b":
+		b.emphasis("**", ml.inline(n))
+
+	case "em", "i":
+		b.emphasis("*", ml.inline(n))
+
+	case "del", "s", "strike":
+		b.emphasis("~~", ml.inline(n))
+
+	case "code":
+		tl := &textLayout{}
+		b.inline.WriteString(codeSpan(strings.Join(tl.contentLines(n, 0), " ")))
+
+	case "a":
+		text := ml.inline(n)
+		href, ok := n.Attr("href")
+		if !ok {
+			b.inline.WriteString(text)
+			return
+		}
+		title, _ := n.Attr("title")
+		if title == "" && (text == mdEscape(href) || "mailto:"+text == mdEscape(href)) && strings.Contains(href, ":") && !strings.ContainsAny(href, " <>") {
+			b.inline.WriteString("<" + strings.TrimPrefix(href, "mailto:") + ">")
+			return
+		}
+		b.inline.WriteString("[" + text + "](" + mdDestination(href, title) + ")")
+
+	case "img":
+		alt, _ := n.Attr("alt")
+		src, _ := n.Attr("src")
+		title, _ := n.Attr("title")
+		b.inline.WriteString("![" + mdEscape(collapseSpace(alt)) + "](" + mdDestination(src, title) + ")")
+
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.TrimFunc(strings.ReplaceAll(ml.inline(n), hardBreak, " "), isHTMLSpace)
+		if text == "" {
+			return
+		}
+		b.block([]string{strings.Repeat("#", int(name[1]-'0')) + " " + text}, false)
+
+	case "p", "figcaption", "dt":
+		b.block(ml.contentLines(n), false)
+
+	case "html", "body", "div", "section", "article", "main", "header", "footer", "nav", "aside", "figure", "hgroup", "address":
+		b.block(ml.contentLines(n), false)
+
+	case "pre":
+		b.block(codeBlock(n), false)
+
+	case "blockquote":
+		b.block(indentLines(ml.contentLines(n), "> ", "> "), false)
+
+	case "ul", "ol":
+		b.block(ml.list(n), ml.listDepth > 0)
+
+	case "table":
+		if lines, ok := ml.table(n); ok {
+			b.block(lines, false)
+			return
+		}
+		b.block([]string{string(n.HTML())}, false)
+
+	default:
+		if textBlockElements[name] {
+			b.block([]string{string(n.HTML())}, false)
+			return
+		}
+		b.inline.WriteString(string(n.HTML()))
+	}
+}
+
+// addContent adds the content of n, i.e. its InnerHTML, InnerText or children.
+func (ml *mdLayout) addContent(b *mdBuilder, n Node) {
+	switch {
+	case n.InnerHTML != "":
+		b.inline.WriteString(string(n.InnerHTML))
+	case n.InnerText != "":
+		b.inline.WriteString(mdEscape(n.InnerText))
+	default:
+		for _, child := range n.Children {
+			ml.add(b, child)
+		}
+	}
+}
+
+// list returns the items of a `<ul>` or `<ol>` with their markers.
+func (ml *mdLayout) list(n Node) []string {
+	ml.listDepth++
+	defer func() { ml.listDepth-- }()
+
+	number := 1
+	if start, ok := n.Attr("start"); ok {
+		if i, err := strconv.Atoi(start); err == nil {
+			number = i
+		}
+	}
+
+	var lines []string
+	for _, item := range textChildren(n.Children) {
+		if item.Name == "" && strings.TrimSpace(textOf(item)) == "" {
+			continue
+		}
+		marker := "- "
+		if strings.EqualFold(n.Name, "ol") {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		itemLines := ml.contentLines(item)
+		if len(itemLines) == 0 {
+			itemLines = []string{""}
+		}
+		lines = append(lines, indentLines(itemLines, marker, strings.Repeat(" ", len(marker)))...)
+	}
+	return lines
+}
+
+// table returns a GitHub Flavored Markdown table, and false if the table has no
+// header row, cells spanning columns or rows, or block content in its cells.
+func (ml *mdLayout) table(n Node) ([]string, bool) {
+	var caption []string
+	var rows [][]string
+	var aligns []string
+	hasHeader, ok := false, true
+	var collect func(nodes []Node, header bool)
+	collect = func(nodes []Node, header bool) {
+		for _, child := range textChildren(nodes) {
+			switch strings.ToLower(child.Name) {
+			case "caption":
+				caption = ml.contentLines(child)
+			case "thead":
+				collect(child.Children, true)
+			case "tbody", "tfoot":
+				collect(child.Children, false)
+			case "tr":
+				var row []string
+				allHeaders := true
+				for _, cell := range textChildren(child.Children) {
+					switch strings.ToLower(cell.Name) {
+					case "th":
+					case "td":
+						allHeaders = false
+					default:
+						continue
+					}
+					if _, span := cell.Attr("colspan"); span {
+						ok = false
+					}
+					if _, span := cell.Attr("rowspan"); span {
+						ok = false
+					}
+					if len(rows) == 0 {
+						aligns = append(aligns, cellAlign(cell))
+					}
+					cb := &mdBuilder{}
+					ml.addContent(cb, cell)
+					if len(cb.out) > 0 {
+						ok = false // block content
+					}
+					text := strings.TrimFunc(collapseSpace(cb.inline.String()), isHTMLSpace)
+					row = append(row, strings.ReplaceAll(text, hardBreak, "<br>"))
+				}
+				if len(rows) == 0 && (header || allHeaders) && len(row) > 0 {
+					hasHeader = true
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	collect(n.Children, false)
+	if !ok || !hasHeader {
+		return nil, false
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	widths := make([]int, columns)
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := textWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	formatRow := func(row []string) string {
+		var sb strings.Builder
+		sb.WriteString("|")
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			sb.WriteString(" " + cell + strings.Repeat(" ", widths[i]-textWidth(cell)) + " |")
+		}
+		return sb.String()
+	}
+
+	lines := append([]string{}, caption...)
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, formatRow(rows[0]))
+	delimiters := make([]string, columns)
+	for i := range delimiters {
+		align := ""
+		if i < len(aligns) {
+			align = aligns[i]
+		}
+		dashes := strings.Repeat("-", widths[i])
+		switch align {
+		case "left":
+			dashes = ":" + dashes[1:]
+		case "right":
+			dashes = dashes[1:] + ":"
+		case "center":
+			dashes = ":" + dashes[2:] + ":"
+		}
+		delimiters[i] = dashes
+	}
+	lines = append(lines, formatRow(delimiters))
+	for _, row := range rows[1:] {
+		lines = append(lines, formatRow(row))
+	}
+	return lines, true
+}
+
+// cellAlign returns the alignment of a table cell from its `align` attribute or
+// `text-align` style.
+func cellAlign(cell Node) string {
+	if align, ok := cell.Attr("align"); ok {
+		return strings.ToLower(strings.TrimSpace(align))
+	}
+	style, _ := cell.Attr("style")
+	for _, declaration := range strings.Split(style, ";") {
+		property, value, found := strings.Cut(declaration, ":")
+		if found && strings.EqualFold(strings.TrimSpace(property), "text-align") {
+			return strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// mdBuilder collects the lines of Markdown blocks. Inline content is gathered into
+// a paragraph, which is written when a block starts or the builder is finished.
+type mdBuilder struct {
+	out    []string
+	inline strings.Builder
+}
+
+// emphasis writes text between the delimiters, keeping surrounding whitespace
+// outside, where Markdown expects it.
+func (b *mdBuilder) emphasis(delimiter, text string) {
+	trimmed := strings.TrimFunc(text, isHTMLSpace)
+	if trimmed == "" {
+		b.inline.WriteString(text)
+		return
+	}
+	if trimmed != text && isHTMLSpace(rune(text[0])) {
+		b.inline.WriteByte(' ')
+	}
+	b.inline.WriteString(delimiter + trimmed + delimiter)
+	if trimmed != text && isHTMLSpace(rune(text[len(text)-1])) {
+		b.inline.WriteByte(' ')
+	}
+}
+
+// block adds the lines of a block, after the paragraph so far. Blocks are set apart
+// by blank lines, unless tight, e.g. a list inside a list item.
+func (b *mdBuilder) block(lines []string, tight bool) {
+	b.flush()
+	if len(lines) == 0 {
+		return
+	}
+	if len(b.out) > 0 && !tight {
+		b.out = append(b.out, "")
+	}
+	b.out = append(b.out, lines...)
+}
+
+// flush writes the paragraph so far as lines.
+func (b *mdBuilder) flush() {
+	text := collapseSpace(b.inline.String())
+	b.inline.Reset()
+	var lines []string
+	for _, line := range strings.Split(text, hardBreak) {
+		lines = append(lines, mdEscapeLineStart(strings.TrimFunc(line, isHTMLSpace)))
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i := range lines[:maxInt(len(lines)-1, 0)] {
+		lines[i] += `\`
+	}
+	if len(lines) > 0 {
+		if len(b.out) > 0 {
+			b.out = append(b.out, "")
+		}
+		b.out = append(b.out, lines...)
+	}
+}
+
+func (b *mdBuilder) finish() []string {
+	b.flush()
+	return b.out
+}
+
+// collapseSpace replaces every run of html whitespace in s with a single space.
+func collapseSpace(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range s {
+		if isHTMLSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		sb.WriteRune(r)
+	}
+	if space {
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+// mdEscape escapes the characters of text that Markdown would take as markup.
+func mdEscape(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '\\', '`', '*', '_', '[', ']', '<', '>', '|', '~':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '&':
+			if isEntityAt(text, i) {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// isEntityAt reports whether the `&` at text[i] starts what Markdown would take as
+// a character reference, e.g. `&amp;` or `&#39;`.
+func isEntityAt(text string, i int) bool {
+	end := strings.IndexByte(text[i:], ';')
+	if end < 2 || end > 32 {
+		return false
+	}
+	for j := i + 1; j < i+end; j++ {
+		c := text[j]
+		if !(isASCIILetter(c) || (c >= '0' && c <= '9') || (j == i+1 && c == '#')) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// mdEscapeLineStart escapes what would start a heading, list, quote or thematic
+// break at the start of a line of text.
+func mdEscapeLineStart(line string) string {
+	if line == "" {
+		return line
+	}
+	switch line[0] {
+	case '#', '>', '+', '-', '=':
+		return `\` + line
+	}
+	digits := 0
+	for digits < len(line) && digits < 9 && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && digits < len(line) && (line[digits] == '.' || line[digits] == ')') &&
+		(digits+1 == len(line) || line[digits+1] == ' ') {
+		return line[:digits] + `\` + line[digits:]
+	}
+	return line
+}
+
+// mdDestination returns the destination and title of a link or image.
+func mdDestination(href, title string) string {
+	dest := strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(href)
+	if title == "" {
+		return dest
+	}
+	return dest + ` "` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(title) + `"`
+}
+
+// codeSpan returns text as a code span, delimited by more backticks than it has in
+// a row.
+func codeSpan(text string) string {
+	if text == "" {
+		return ""
+	}
+	fence := strings.Repeat("`", longestRun(text, '`')+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// codeBlock returns the text of a `<pre>` as a fenced code block, with the
+// language of a `class="language-go"` on it or its `<code>`.
+func codeBlock(n Node) []string {
+	language := codeLanguage(n)
+	for _, child := range textChildren(n.Children) {
+		if strings.EqualFold(child.Name, "code") && language == "" {
+			language = codeLanguage(child)
+		}
+	}
+
+	pre := &textBuilder{pre: true}
+	tl := &textLayout{}
+	tl.addContent(pre, n)
+	lines := pre.finish()
+
+	longest := 0
+	for _, line := range lines {
+		if run := longestRun(line, '`'); run > longest {
+			longest = run
+		}
+	}
+	fence := strings.Repeat("`", maxInt(3, longest+1))
+	return append(append([]string{fence + language}, lines...), fence)
+}
+
+func codeLanguage(n Node) string {
+	class, _ := n.Attr("class")
+	for _, name := range strings.Fields(class) {
+		if strings.HasPrefix(name, "language-") {
+			return strings.TrimPrefix(name, "language-")
+		}
+		if strings.HasPrefix(name, "lang-") {
+			return strings.TrimPrefix(name, "lang-")
+		}
+	}
+	return ""
+}
+
+// longestRun returns the length of the longest run of c in s.
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != c {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,871 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// nodeType is the type of a node of parsed Markdown.
+type nodeType int
+
+const (
+	// blocks
+	document nodeType = iota
+	blockQuote
+	list
+	item
+	paragraph
+	heading
+	thematicBreak
+	codeBlock
+	htmlBlock
+	table
+
+	// inlines
+	text
+	softBreak
+	hardBreak
+	emphasis
+	strong
+	strikethrough
+	code
+	htmlInline
+	link
+	image
+)
+
+// task is the checkbox of a task list item.
+type task int
+
+const (
+	noTask task = iota
+	uncheckedTask
+	checkedTask
+)
+
+// node is a block or an inline of parsed Markdown.
+type node struct {
+	typ                             nodeType
+	parent, first, last, prev, next *node
+
+	// blocks
+	open               bool
+	content            strings.Builder // lines of paragraphs, code and html blocks so far
+	startLine, endLine int             // the first and last line with content, to tell tight lists
+	level              int             // of headings
+	fenced             bool
+	fenceChar          byte
+	fenceLength        int
+	fenceOffset        int
+	info               string   // of fenced code blocks
+	list               listData // of lists and list items
+	tight              bool     // of lists
+	task               task     // of list items
+	htmlType           int      // of html blocks, the number of its start condition
+	aligns             []string // of tables
+	rows               [][]string
+
+	// blocks and inlines
+	literal string
+
+	// links and images
+	dest, title string
+}
+
+// listData describes the marker of a list item.
+type listData struct {
+	ordered      bool
+	bulletChar   byte
+	start        int
+	delimiter    byte
+	markerOffset int // indent of the marker
+	padding      int // width of the marker and the spaces after it
+}
+
+func (n *node) appendChild(child *node) {
+	child.unlink()
+	child.parent = n
+	if n.last != nil {
+		n.last.next = child
+		child.prev = n.last
+		n.last = child
+	} else {
+		n.first = child
+		n.last = child
+	}
+}
+
+func (n *node) insertAfter(sibling *node) {
+	sibling.unlink()
+	sibling.next = n.next
+	if sibling.next != nil {
+		sibling.next.prev = sibling
+	}
+	sibling.prev = n
+	n.next = sibling
+	sibling.parent = n.parent
+	if sibling.next == nil && sibling.parent != nil {
+		sibling.parent.last = sibling
+	}
+}
+
+func (n *node) unlink() {
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else if n.parent != nil {
+		n.parent.first = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else if n.parent != nil {
+		n.parent.last = n.prev
+	}
+	n.parent, n.next, n.prev = nil, nil, nil
+}
+
+// endsWithBlankLine reports whether there is a blank line between n and the next
+// block.
+func (n *node) endsWithBlankLine() bool {
+	return n.next != nil && n.next.startLine > n.endLine+1
+}
+
+// blockParser builds the block structure of a document line by line, following
+// the parsing strategy of the CommonMark spec.
+type blockParser struct {
+	opts   Options
+	refmap map[string]linkRef
+	refs   *inlineParser
+
+	doc, tip, oldtip     *node
+	lastMatchedContainer *node
+	allClosed            bool
+
+	line                 string
+	lineNumber           int
+	offset, column       int
+	nextNonspace         int
+	nextNonspaceColumn   int
+	indent               int
+	indented, blank      bool
+	partiallyConsumedTab bool
+}
+
+func newBlockParser(opts Options) *blockParser {
+	refmap := map[string]linkRef{}
+	return &blockParser{
+		opts:   opts,
+		refmap: refmap,
+		refs:   &inlineParser{refmap: refmap},
+	}
+}
+
+func (p *blockParser) parse(source string) *node {
+	p.doc = &node{typ: document, open: true}
+	p.tip = p.doc
+	source = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\x00", "\uFFFD").Replace(source)
+	lines := strings.Split(source, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for _, line := range lines {
+		p.incorporateLine(line)
+	}
+	for p.tip != nil {
+		p.finalize(p.tip)
+	}
+	markTasks(p.doc)
+	return p.doc
+}
+
+// incorporateLine adds a line to the document: it finds the open blocks the line
+// continues, the new blocks it starts, and adds what remains to the innermost.
+func (p *blockParser) incorporateLine(line string) {
+	container := p.doc
+	p.oldtip = p.tip
+	p.line = line
+	p.offset, p.column = 0, 0
+	p.blank, p.partiallyConsumedTab = false, false
+	p.lineNumber++
+
+	for container.last != nil && container.last.open {
+		container = container.last
+		p.findNextNonspace()
+		switch p.continues(container) {
+		case continued:
+			continue
+		case notContinued:
+			container = container.parent
+		case lineDone:
+			return
+		}
+		break
+	}
+
+	p.allClosed = container == p.oldtip
+	p.lastMatchedContainer = container
+
+	matchedLeaf := container.typ != paragraph && container.typ != table && acceptsLines(container.typ)
+	for !matchedLeaf {
+		p.findNextNonspace()
+		if !p.indented && !maybeSpecial(p.line[p.nextNonspace:]) {
+			p.advanceNextNonspace()
+			break
+		}
+		started := notStarted
+		for _, start := range blockStarts {
+			if started = start(p, container); started != notStarted {
+				break
+			}
+		}
+		if started == notStarted {
+			p.advanceNextNonspace()
+			break
+		}
+		container = p.tip
+		matchedLeaf = started == leafStarted
+	}
+
+	if !p.allClosed && !p.blank && p.tip.typ == paragraph {
+		p.addLine() // lazy continuation line
+		return
+	}
+	p.closeUnmatchedBlocks()
+	switch {
+	case acceptsLines(container.typ):
+		p.addLine()
+		if container.typ == htmlBlock && container.htmlType <= 5 && htmlBlockClose[container.htmlType].MatchString(p.line[p.offset:]) {
+			p.finalize(container)
+		}
+	case p.offset < len(p.line) && !p.blank:
+		p.addChild(paragraph)
+		p.advanceNextNonspace()
+		p.addLine()
+	}
+}
+
+// results of blockParser.continues
+const (
+	continued = iota
+	notContinued
+	lineDone // e.g. the closing fence of a code block
+)
+
+// continues consumes the part of the line that continues the open block c.
+func (p *blockParser) continues(c *node) int {
+	switch c.typ {
+	case blockQuote:
+		if p.indented || p.peekNonspace() != '>' {
+			return notContinued
+		}
+		p.advanceNextNonspace()
+		p.advanceOffset(1, false)
+		if isSpaceOrTab(p.peek()) {
+			p.advanceOffset(1, true)
+		}
+
+	case item:
+		switch {
+		case p.blank && c.first == nil:
+			return notContinued // a list item can begin with at most one blank line
+		case p.blank:
+			p.advanceNextNonspace()
+		case p.indent >= c.list.markerOffset+c.list.padding:
+			p.advanceOffset(c.list.markerOffset+c.list.padding, true)
+		default:
+			return notContinued
+		}
+
+	case heading, thematicBreak:
+		return notContinued
+
+	case codeBlock:
+		switch {
+		case c.fenced:
+			rest := p.line[p.nextNonspace:]
+			if n := runLength(rest, c.fenceChar); !p.indented && n >= c.fenceLength && strings.Trim(rest[n:], " \t") == "" {
+				p.markContent(c)
+				p.finalize(c)
+				return lineDone
+			}
+			for i := c.fenceOffset; i > 0 && isSpaceOrTab(p.peek()); i-- {
+				p.advanceOffset(1, true)
+			}
+		case p.indent >= 4:
+			p.advanceOffset(4, true)
+		case p.blank:
+			p.advanceNextNonspace()
+		default:
+			return notContinued
+		}
+
+	case htmlBlock:
+		if p.blank && (c.htmlType == 6 || c.htmlType == 7) {
+			return notContinued
+		}
+
+	case paragraph, table:
+		if p.blank {
+			return notContinued
+		}
+	}
+	return continued
+}
+
+// results of a blockStart
+const (
+	notStarted = iota
+	containerStarted
+	leafStarted
+)
+
+// blockStarts try to start a block at the current position, in order.
+var blockStarts = []func(p *blockParser, container *node) int{
+	blockQuoteStart,
+	atxHeadingStart,
+	fencedCodeStart,
+	htmlBlockStart,
+	tableStart,
+	setextHeadingStart,
+	thematicBreakStart,
+	listItemStart,
+	indentedCodeStart,
+}
+
+func blockQuoteStart(p *blockParser, container *node) int {
+	if p.indented || p.peekNonspace() != '>' {
+		return notStarted
+	}
+	p.advanceNextNonspace()
+	p.advanceOffset(1, false)
+	if isSpaceOrTab(p.peek()) {
+		p.advanceOffset(1, true)
+	}
+	p.closeUnmatchedBlocks()
+	p.addChild(blockQuote)
+	return containerStarted
+}
+
+var (
+	reATXHeading       = regexp.MustCompile(`^#{1,6}(?:[ \t]+|$)`)
+	reATXClosingOnly   = regexp.MustCompile(`^[ \t]*#+[ \t]*$`)
+	reATXClosing       = regexp.MustCompile(`[ \t]+#+[ \t]*$`)
+	reSetextHeading    = regexp.MustCompile(`^(?:=+|-+)[ \t]*$`)
+	reThematicBreak    = regexp.MustCompile(`^(?:(?:\*[ \t]*){3,}|(?:_[ \t]*){3,}|(?:-[ \t]*){3,})$`)
+	reBulletListMarker = regexp.MustCompile(`^[*+-]`)
+	reOrderedListMark  = regexp.MustCompile(`^(\d{1,9})([.)])`)
+	reTableDelimiter   = regexp.MustCompile(`^:?-+:?$`)
+	reTrailingBlanks   = regexp.MustCompile(`(\n[ \t]*)+$`)
+)
+
+func atxHeadingStart(p *blockParser, container *node) int {
+	if p.indented {
+		return notStarted
+	}
+	marker := reATXHeading.FindString(p.line[p.nextNonspace:])
+	if marker == "" {
+		return notStarted
+	}
+	p.advanceNextNonspace()
+	p.advanceOffset(len(marker), false)
+	p.closeUnmatchedBlocks()
+	h := p.addChild(heading)
+	h.level = strings.Count(marker, "#")
+	content := reATXClosingOnly.ReplaceAllString(p.line[p.offset:], "")
+	h.literal = reATXClosing.ReplaceAllString(content, "")
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return leafStarted
+}
+
+func fencedCodeStart(p *blockParser, container *node) int {
+	if p.indented {
+		return notStarted
+	}
+	rest := p.line[p.nextNonspace:]
+	if rest == "" || (rest[0] != '`' && rest[0] != '~') {
+		return notStarted
+	}
+	n := runLength(rest, rest[0])
+	if n < 3 || (rest[0] == '`' && strings.IndexByte(rest[n:], '`') >= 0) {
+		return notStarted
+	}
+	p.closeUnmatchedBlocks()
+	c := p.addChild(codeBlock)
+	c.fenced, c.fenceChar, c.fenceLength, c.fenceOffset = true, rest[0], n, p.indent
+	p.advanceNextNonspace()
+	p.advanceOffset(n, false)
+	return leafStarted
+}
+
+func htmlBlockStart(p *blockParser, container *node) int {
+	if !p.opts.AllowHTML || p.indented || p.peekNonspace() != '<' {
+		return notStarted
+	}
+	rest := p.line[p.nextNonspace:]
+	for t := 1; t <= 7; t++ {
+		if !htmlBlockOpen[t].MatchString(rest) {
+			continue
+		}
+		if t == 7 && (container.typ == paragraph || (!p.allClosed && !p.blank && p.tip.typ == paragraph)) {
+			continue // cannot interrupt a paragraph
+		}
+		p.closeUnmatchedBlocks()
+		b := p.addChild(htmlBlock)
+		b.htmlType = t
+		return leafStarted
+	}
+	return notStarted
+}
+
+// tableStart turns the last line of a paragraph into the header row of a table, when
+// the line is a delimiter row with as many cells.
+func tableStart(p *blockParser, container *node) int {
+	if p.indented || container.typ != paragraph {
+		return notStarted
+	}
+	delimiters := p.line[p.nextNonspace:]
+	aligns, ok := parseDelimiterRow(delimiters)
+	if !ok {
+		return notStarted
+	}
+	lines := strings.Split(strings.TrimSuffix(container.content.String(), "\n"), "\n")
+	header := lines[len(lines)-1]
+	cells := splitRow(header)
+	if len(cells) != len(aligns) || (!strings.Contains(delimiters, "|") && !strings.Contains(header, "|")) {
+		return notStarted
+	}
+
+	p.closeUnmatchedBlocks()
+	if len(lines) > 1 {
+		container.content.Reset()
+		container.content.WriteString(strings.Join(lines[:len(lines)-1], "\n") + "\n")
+		container.endLine--
+		p.finalize(container)
+	} else {
+		p.tip = container.parent
+		container.unlink()
+	}
+	t := p.addChild(table)
+	t.startLine--
+	t.aligns = aligns
+	t.rows = [][]string{cells}
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return leafStarted
+}
+
+func setextHeadingStart(p *blockParser, container *node) int {
+	if p.indented || container.typ != paragraph {
+		return notStarted
+	}
+	underline := p.line[p.nextNonspace:]
+	if !reSetextHeading.MatchString(underline) {
+		return notStarted
+	}
+	p.closeUnmatchedBlocks()
+	content := p.parseReferences(container.content.String())
+	container.content.Reset()
+	container.content.WriteString(content)
+	if content == "" {
+		return notStarted // only link reference definitions; the underline is a thematic break
+	}
+	h := &node{typ: heading, open: true, literal: content, startLine: container.startLine, endLine: p.lineNumber}
+	h.level = 2
+	if underline[0] == '=' {
+		h.level = 1
+	}
+	container.insertAfter(h)
+	container.unlink()
+	p.tip = h
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return leafStarted
+}
+
+func thematicBreakStart(p *blockParser, container *node) int {
+	if p.indented || !reThematicBreak.MatchString(p.line[p.nextNonspace:]) {
+		return notStarted
+	}
+	p.closeUnmatchedBlocks()
+	p.addChild(thematicBreak)
+	p.advanceOffset(len(p.line)-p.offset, false)
+	return leafStarted
+}
+
+func listItemStart(p *blockParser, container *node) int {
+	if p.indented && container.typ != list {
+		return notStarted
+	}
+	data, ok := p.parseListMarker(container)
+	if !ok {
+		return notStarted
+	}
+	p.closeUnmatchedBlocks()
+	if p.tip.typ != list || !listsMatch(container.list, data) {
+		l := p.addChild(list)
+		l.list = data
+	}
+	it := p.addChild(item)
+	it.list = data
+	return containerStarted
+}
+
+func indentedCodeStart(p *blockParser, container *node) int {
+	if !p.indented || p.tip.typ == paragraph || p.blank {
+		return notStarted
+	}
+	p.advanceOffset(4, true)
+	p.closeUnmatchedBlocks()
+	p.addChild(codeBlock)
+	return leafStarted
+}
+
+// parseListMarker consumes a list item marker and the spaces after it.
+func (p *blockParser) parseListMarker(container *node) (listData, bool) {
+	if p.indent >= 4 {
+		return listData{}, false
+	}
+	rest := p.line[p.nextNonspace:]
+	data := listData{markerOffset: p.indent}
+	var marker string
+	if m := reBulletListMarker.FindString(rest); m != "" {
+		marker, data.bulletChar = m, m[0]
+	} else if m := reOrderedListMark.FindStringSubmatch(rest); m != nil && (container.typ != paragraph || m[1] == "1") {
+		marker, data.ordered, data.delimiter = m[0], true, m[2][0]
+		data.start = atoi(m[1])
+	} else {
+		return listData{}, false
+	}
+
+	after := rest[len(marker):]
+	if after != "" && !isSpaceOrTab(after[0]) {
+		return listData{}, false
+	}
+	if container.typ == paragraph && strings.Trim(after, " \t") == "" {
+		return listData{}, false // an empty list item cannot interrupt a paragraph
+	}
+
+	p.advanceNextNonspace()
+	p.advanceOffset(len(marker), true)
+	spacesStartColumn, spacesStartOffset := p.column, p.offset
+	for {
+		p.advanceOffset(1, true)
+		if p.column-spacesStartColumn >= 5 || !isSpaceOrTab(p.peek()) {
+			break
+		}
+	}
+	blankItem := p.offset >= len(p.line)
+	spacesAfterMarker := p.column - spacesStartColumn
+	if spacesAfterMarker >= 5 || spacesAfterMarker < 1 || blankItem {
+		// the content is indented code, or starts on the next line
+		data.padding = len(marker) + 1
+		p.column, p.offset = spacesStartColumn, spacesStartOffset
+		p.partiallyConsumedTab = false
+		if isSpaceOrTab(p.peek()) {
+			p.advanceOffset(1, true)
+		}
+	} else {
+		data.padding = len(marker) + spacesAfterMarker
+	}
+	return data, true
+}
+
+func listsMatch(a, b listData) bool {
+	return a.ordered == b.ordered && a.delimiter == b.delimiter && a.bulletChar == b.bulletChar
+}
+
+// parseDelimiterRow returns the alignments of the columns of a table delimiter row,
+// e.g. `| :-- | --: |`.
+func parseDelimiterRow(line string) ([]string, bool) {
+	cells := splitRow(line)
+	if len(cells) == 0 {
+		return nil, false
+	}
+	aligns := make([]string, len(cells))
+	for i, cell := range cells {
+		if !reTableDelimiter.MatchString(cell) {
+			return nil, false
+		}
+		left, right := cell[0] == ':', cell[len(cell)-1] == ':'
+		switch {
+		case left && right:
+			aligns[i] = "center"
+		case left:
+			aligns[i] = "left"
+		case right:
+			aligns[i] = "right"
+		}
+	}
+	return aligns, true
+}
+
+// splitRow returns the cells of a table row, split at the pipes that are not
+// escaped.
+func splitRow(line string) []string {
+	line = strings.Trim(line, " \t")
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	if strings.Trim(line, " \t") == "" {
+		return nil
+	}
+	var cells []string
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, line[start:i])
+			start = i + 1
+		}
+	}
+	cells = append(cells, line[start:])
+	for i, cell := range cells {
+		cells[i] = strings.ReplaceAll(strings.Trim(cell, " \t"), `\|`, "|")
+	}
+	return cells
+}
+
+func acceptsLines(t nodeType) bool {
+	return t == paragraph || t == codeBlock || t == htmlBlock || t == table
+}
+
+func canContain(parent, child nodeType) bool {
+	switch parent {
+	case document, blockQuote, item:
+		return child != item
+	case list:
+		return child == item
+	}
+	return false
+}
+
+// maybeSpecial reports whether s could start a block other than a paragraph.
+func maybeSpecial(s string) bool {
+	if s == "" {
+		return false
+	}
+	switch c := s[0]; {
+	case c >= '0' && c <= '9':
+		return true
+	default:
+		return strings.IndexByte("#`~*+_=<>-|:", c) >= 0
+	}
+}
+
+// addChild adds a new block to the innermost block that can contain it, closing the
+// blocks that cannot.
+func (p *blockParser) addChild(typ nodeType) *node {
+	for !canContain(p.tip.typ, typ) {
+		p.finalize(p.tip)
+	}
+	n := &node{typ: typ, open: true, startLine: p.lineNumber}
+	p.tip.appendChild(n)
+	p.tip = n
+	p.markContent(n)
+	return n
+}
+
+// markContent records that the current line has content of b.
+func (p *blockParser) markContent(b *node) {
+	for ; b != nil; b = b.parent {
+		b.endLine = p.lineNumber
+	}
+}
+
+// addLine adds the rest of the line to the innermost block.
+func (p *blockParser) addLine() {
+	if p.partiallyConsumedTab {
+		p.offset++ // skip over the tab, and add the columns of it that remain
+		p.tip.content.WriteString(strings.Repeat(" ", 4-p.column%4))
+	}
+	if p.tip.typ == table {
+		if row := p.line[p.offset:]; strings.Trim(row, " \t") != "" {
+			p.tip.rows = append(p.tip.rows, splitRow(row))
+		}
+	} else {
+		p.tip.content.WriteString(p.line[p.offset:])
+		p.tip.content.WriteByte('\n')
+	}
+	if !p.blank || (p.tip.typ == codeBlock && p.tip.fenced) {
+		p.markContent(p.tip)
+	}
+}
+
+// closeUnmatchedBlocks finalizes the blocks that the line did not continue.
+func (p *blockParser) closeUnmatchedBlocks() {
+	if p.allClosed {
+		return
+	}
+	for p.oldtip != p.lastMatchedContainer {
+		parent := p.oldtip.parent
+		p.finalize(p.oldtip)
+		p.oldtip = parent
+	}
+	p.allClosed = true
+}
+
+// finalize closes block b, which is p.tip or an ancestor of it.
+func (p *blockParser) finalize(b *node) {
+	parent := b.parent
+	b.open = false
+	switch b.typ {
+	case paragraph:
+		content := b.content.String()
+		rest := p.parseReferences(content)
+		if strings.Trim(rest, " \t\n") == "" {
+			b.unlink()
+		}
+		b.literal = rest
+
+	case codeBlock:
+		content := b.content.String()
+		if b.fenced {
+			firstLine, rest, _ := strings.Cut(content, "\n")
+			b.info = unescapeString(strings.Trim(firstLine, " \t"))
+			b.literal = rest
+		} else {
+			b.literal = reTrailingBlanks.ReplaceAllString(content, "\n")
+		}
+
+	case htmlBlock:
+		b.literal = reTrailingBlanks.ReplaceAllString(b.content.String(), "")
+
+	case list:
+		b.tight = true
+		for it := b.first; it != nil && b.tight; it = it.next {
+			if it.endsWithBlankLine() {
+				b.tight = false
+				break
+			}
+			for sub := it.first; sub != nil; sub = sub.next {
+				if sub.endsWithBlankLine() {
+					b.tight = false
+					break
+				}
+			}
+		}
+	}
+	p.tip = parent
+}
+
+// parseReferences removes the link reference definitions at the start of the
+// content of a paragraph, remembering them, and returns the rest.
+func (p *blockParser) parseReferences(content string) string {
+	for strings.HasPrefix(content, "[") {
+		n := p.refs.parseReference(content)
+		if n == 0 {
+			break
+		}
+		content = content[n:]
+	}
+	return content
+}
+
+var reTaskMarker = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+
+// markTasks finds the list items starting with `[ ]` or `[x]`.
+func markTasks(n *node) {
+	for c := n.first; c != nil; c = c.next {
+		if c.typ == item && c.first != nil && c.first.typ == paragraph {
+			if m := reTaskMarker.FindStringSubmatch(c.first.literal); m != nil {
+				c.task = uncheckedTask
+				if m[1] != " " {
+					c.task = checkedTask
+				}
+				c.first.literal = c.first.literal[len(m[0]):]
+			}
+		}
+		markTasks(c)
+	}
+}
+
+func (p *blockParser) findNextNonspace() {
+	i, cols := p.offset, p.column
+	for i < len(p.line) {
+		if p.line[i] == ' ' {
+			i++
+			cols++
+		} else if p.line[i] == '\t' {
+			i++
+			cols += 4 - cols%4
+		} else {
+			break
+		}
+	}
+	p.blank = i >= len(p.line)
+	p.nextNonspace, p.nextNonspaceColumn = i, cols
+	p.indent = cols - p.column
+	p.indented = p.indent >= 4
+}
+
+func (p *blockParser) advanceNextNonspace() {
+	p.offset, p.column = p.nextNonspace, p.nextNonspaceColumn
+	p.partiallyConsumedTab = false
+}
+
+// advanceOffset consumes count characters, or count columns if columns is set, in
+// which case a tab may be partially consumed.
+func (p *blockParser) advanceOffset(count int, columns bool) {
+	for count > 0 && p.offset < len(p.line) {
+		if p.line[p.offset] != '\t' {
+			p.partiallyConsumedTab = false
+			p.offset++
+			p.column++
+			count--
+			continue
+		}
+		charsToTab := 4 - p.column%4
+		if !columns {
+			p.partiallyConsumedTab = false
+			p.column += charsToTab
+			p.offset++
+			count--
+			continue
+		}
+		p.partiallyConsumedTab = charsToTab > count
+		advance := charsToTab
+		if advance > count {
+			advance = count
+		}
+		p.column += advance
+		if !p.partiallyConsumedTab {
+			p.offset++
+		}
+		count -= advance
+	}
+}
+
+func (p *blockParser) peek() byte {
+	if p.offset < len(p.line) {
+		return p.line[p.offset]
+	}
+	return 0
+}
+
+func (p *blockParser) peekNonspace() byte {
+	if p.nextNonspace < len(p.line) {
+		return p.line[p.nextNonspace]
+	}
+	return 0
+}
+
+func isSpaceOrTab(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// runLength returns the number of c that s starts with.
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func atoi(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		n = n*10 + int(s[i]-'0')
+	}
+	return n
+}
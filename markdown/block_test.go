@@ -0,0 +1,81 @@
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/choonkeat/dom-go/markdown"
+)
+
+func TestParseBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		want  string
+	}{
+		{
+			name:  "atx and setext headings, thematic break",
+			given: "## Two ##\n\nTitle\n=====\n\nSub\n---\n\n***\n",
+			want:  "<h2>Two</h2><h1>Title</h1><h2>Sub</h2><hr/>",
+		},
+		{
+			name:  "tight and loose lists",
+			given: "- a\n- b\n  - c\n\n- d\n\n+ e\n+ f\n",
+			want:  "<ul><li><p>a</p></li><li><p>b</p><ul><li>c</li></ul></li><li><p>d</p></li></ul><ul><li>e</li><li>f</li></ul>",
+		},
+		{
+			name:  "ordered list with start and continuation",
+			given: "3. one\n4. two\n\n   para\n",
+			want:  `<ol start="3"><li><p>one</p></li><li><p>two</p><p>para</p></li></ol>`,
+		},
+		{
+			name:  "task list",
+			given: "- [ ] todo\n- [x] done\n- [y] not a task\n",
+			want:  `<ul><li><input type="checkbox" disabled=""/> todo</li><li><input type="checkbox" disabled="" checked=""/> done</li><li>[y] not a task</li></ul>`,
+		},
+		{
+			name:  "block quotes with lazy continuation",
+			given: "> quote\ncontinued\n> > nested\n",
+			want:  "<blockquote><p>quote\ncontinued</p><blockquote><p>nested</p></blockquote></blockquote>",
+		},
+		{
+			name:  "fenced code",
+			given: "```go\nfunc main() {\n\tfmt.Println(\"<hi>\")\n}\n```\n\n~~~\nunclosed",
+			want:  `<pre><code class="language-go">func main() {` + "\n\tfmt.Println(&#34;&lt;hi&gt;&#34;)\n}\n" + `</code></pre><pre><code>unclosed` + "\n" + `</code></pre>`,
+		},
+		{
+			name:  "indented code",
+			given: "    indented\n\n    code\n",
+			want:  "<pre><code>indented\n\ncode\n</code></pre>",
+		},
+		{
+			name:  "tables",
+			given: "| a | b |\n|:--|--:|\n| 1 | 2 \\| 3 |\n| x |\n\n| c |\n| - |\n",
+			want: `<table><thead><tr><th align="left">a</th><th align="right">b</th></tr></thead>` +
+				`<tbody><tr><td align="left">1</td><td align="right">2 | 3</td></tr><tr><td align="left">x</td><td align="right"></td></tr></tbody></table>` +
+				`<table><thead><tr><th>c</th></tr></thead></table>`,
+		},
+		{
+			name:  "table needs matching delimiter row",
+			given: "| a | b |\n| - |\n",
+			want:  "<p>| a | b |\n| - |</p>",
+		},
+		{
+			name:  "link reference definitions",
+			given: "[ref] and [Ref][] and [x][ref]\n\n[ref]: /url 'title'\n",
+			want:  `<p><a href="/url" title="title">ref</a> and <a href="/url" title="title">Ref</a> and <a href="/url" title="title">x</a></p>`,
+		},
+		{
+			name:  "nul is replaced",
+			given: "a\x00b",
+			want:  "<p>a�b</p>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(markdown.Parse(tt.given, markdown.Options{}).HTML())
+			if got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+		})
+	}
+}
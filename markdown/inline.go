@@ -0,0 +1,802 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// linkRef is a link reference definition, e.g. `[docs]: https://example.com "Docs"`.
+type linkRef struct {
+	dest, title string
+}
+
+// inlineParser parses the inline content of a block, following the delimiter stack
+// algorithm of the CommonMark spec for emphasis and links.
+type inlineParser struct {
+	refmap    map[string]linkRef
+	allowHTML bool
+
+	subject    string
+	pos        int
+	delimiters *delimiter // top of the delimiter stack
+	brackets   *bracket   // top of the stack of `[` and `![`
+}
+
+// delimiter is a run of `*`, `_` or `~` that may open or close emphasis.
+type delimiter struct {
+	cc                byte
+	numdelims         int
+	origdelims        int
+	node              *node
+	previous, next    *delimiter
+	canOpen, canClose bool
+}
+
+// bracket is a `[` or `![` that may start a link or an image.
+type bracket struct {
+	node              *node
+	previous          *bracket
+	previousDelimiter *delimiter
+	index             int // of the text after the bracket
+	image             bool
+	active            bool
+	bracketAfter      bool
+}
+
+// parse adds the inlines of s to block.
+func (ip *inlineParser) parse(block *node, s string) {
+	ip.subject = strings.TrimSpace(s)
+	ip.pos = 0
+	ip.delimiters = nil
+	ip.brackets = nil
+	for ip.parseInline(block) {
+	}
+	ip.processEmphasis(nil)
+}
+
+func (ip *inlineParser) parseInline(block *node) bool {
+	if ip.pos >= len(ip.subject) {
+		return false
+	}
+	c := ip.subject[ip.pos]
+	var ok bool
+	switch c {
+	case '\n':
+		ok = ip.parseNewline(block)
+	case '\\':
+		ok = ip.parseBackslash(block)
+	case '`':
+		ok = ip.parseBackticks(block)
+	case '*', '_', '~':
+		ok = ip.handleDelim(c, block)
+	case '[':
+		ok = ip.parseOpenBracket(block)
+	case '!':
+		ok = ip.parseBang(block)
+	case ']':
+		ok = ip.parseCloseBracket(block)
+	case '<':
+		ok = ip.parseAutolink(block) || ip.parseHTMLTag(block)
+	case '&':
+		ok = ip.parseEntity(block)
+	default:
+		ok = ip.parseString(block)
+	}
+	if !ok {
+		ip.pos++
+		block.appendChild(textNode(string(c)))
+	}
+	return true
+}
+
+func textNode(s string) *node {
+	return &node{typ: text, literal: s}
+}
+
+func (ip *inlineParser) peek() byte {
+	if ip.pos < len(ip.subject) {
+		return ip.subject[ip.pos]
+	}
+	return 0
+}
+
+// parseString adds the text up to the next character that may start markup.
+func (ip *inlineParser) parseString(block *node) bool {
+	start := ip.pos
+	for ip.pos < len(ip.subject) && strings.IndexByte("\n`[]\\!<&*_~", ip.subject[ip.pos]) < 0 {
+		ip.pos++
+	}
+	if ip.pos == start {
+		return false
+	}
+	block.appendChild(textNode(ip.subject[start:ip.pos]))
+	return true
+}
+
+// parseNewline adds a hard line break after two or more spaces, or a soft one.
+func (ip *inlineParser) parseNewline(block *node) bool {
+	ip.pos++
+	last := block.last
+	if last != nil && last.typ == text && strings.HasSuffix(last.literal, " ") {
+		hard := strings.HasSuffix(last.literal, "  ")
+		last.literal = strings.TrimRight(last.literal, " ")
+		if hard {
+			block.appendChild(&node{typ: hardBreak})
+		} else {
+			block.appendChild(&node{typ: softBreak})
+		}
+	} else {
+		block.appendChild(&node{typ: softBreak})
+	}
+	for ip.peek() == ' ' {
+		ip.pos++
+	}
+	return true
+}
+
+func (ip *inlineParser) parseBackslash(block *node) bool {
+	ip.pos++
+	switch c := ip.peek(); {
+	case c == '\n':
+		ip.pos++
+		block.appendChild(&node{typ: hardBreak})
+	case isASCIIPunct(c):
+		ip.pos++
+		block.appendChild(textNode(string(c)))
+	default:
+		block.appendChild(textNode(`\`))
+	}
+	return true
+}
+
+// parseBackticks adds a code span, or the backticks as text if they are not closed.
+func (ip *inlineParser) parseBackticks(block *node) bool {
+	start := ip.pos
+	ticks := runLength(ip.subject[ip.pos:], '`')
+	ip.pos += ticks
+	after := ip.pos
+	for {
+		i := strings.IndexByte(ip.subject[ip.pos:], '`')
+		if i < 0 {
+			break
+		}
+		ip.pos += i
+		n := runLength(ip.subject[ip.pos:], '`')
+		if n == ticks {
+			contents := strings.ReplaceAll(ip.subject[after:ip.pos], "\n", " ")
+			if len(contents) > 2 && contents[0] == ' ' && contents[len(contents)-1] == ' ' && strings.Trim(contents, " ") != "" {
+				contents = contents[1 : len(contents)-1]
+			}
+			ip.pos += n
+			block.appendChild(&node{typ: code, literal: contents})
+			return true
+		}
+		ip.pos += n
+	}
+	ip.pos = after
+	block.appendChild(textNode(ip.subject[start:after]))
+	return true
+}
+
+// handleDelim adds a run of `*`, `_` or `~` as text, and pushes it on the delimiter
+// stack if it may open or close emphasis.
+func (ip *inlineParser) handleDelim(cc byte, block *node) bool {
+	numdelims, canOpen, canClose := ip.scanDelims(cc)
+	start := ip.pos
+	ip.pos += numdelims
+	n := textNode(ip.subject[start:ip.pos])
+	block.appendChild(n)
+	if canOpen || canClose {
+		ip.delimiters = &delimiter{
+			cc:         cc,
+			numdelims:  numdelims,
+			origdelims: numdelims,
+			node:       n,
+			previous:   ip.delimiters,
+			canOpen:    canOpen,
+			canClose:   canClose,
+		}
+		if ip.delimiters.previous != nil {
+			ip.delimiters.previous.next = ip.delimiters
+		}
+	}
+	return true
+}
+
+// scanDelims returns the length of the run of cc at the current position, and
+// whether it is left flanking and right flanking, as far as emphasis goes.
+func (ip *inlineParser) scanDelims(cc byte) (int, bool, bool) {
+	numdelims := runLength(ip.subject[ip.pos:], cc)
+	before, after := '\n', '\n'
+	if ip.pos > 0 {
+		before, _ = utf8.DecodeLastRuneInString(ip.subject[:ip.pos])
+	}
+	if end := ip.pos + numdelims; end < len(ip.subject) {
+		after, _ = utf8.DecodeRuneInString(ip.subject[end:])
+	}
+	afterIsWhitespace, afterIsPunct := isUnicodeWhitespace(after), isUnicodePunct(after)
+	beforeIsWhitespace, beforeIsPunct := isUnicodeWhitespace(before), isUnicodePunct(before)
+	leftFlanking := !afterIsWhitespace && (!afterIsPunct || beforeIsWhitespace || beforeIsPunct)
+	rightFlanking := !beforeIsWhitespace && (!beforeIsPunct || afterIsWhitespace || afterIsPunct)
+
+	switch {
+	case cc == '_':
+		return numdelims, leftFlanking && (!rightFlanking || beforeIsPunct), rightFlanking && (!leftFlanking || afterIsPunct)
+	case cc == '~' && numdelims > 2:
+		return numdelims, false, false
+	}
+	return numdelims, leftFlanking, rightFlanking
+}
+
+// processEmphasis matches the openers and closers on the delimiter stack above
+// stackBottom into emphasis, strong emphasis and strikethrough.
+func (ip *inlineParser) processEmphasis(stackBottom *delimiter) {
+	// where the search for an opener stopped last time, for closers alike
+	type closerKind struct {
+		cc      byte
+		canOpen bool
+		mod3    int
+	}
+	openersBottom := map[closerKind]*delimiter{}
+
+	closer := ip.delimiters
+	for closer != nil && closer.previous != stackBottom {
+		closer = closer.previous
+	}
+	for closer != nil {
+		if !closer.canClose {
+			closer = closer.next
+			continue
+		}
+		cc := closer.cc
+		kind := closerKind{cc, closer.canOpen, closer.origdelims % 3}
+		bottom, ok := openersBottom[kind]
+		if !ok {
+			bottom = stackBottom
+		}
+		opener := closer.previous
+		found := false
+		for opener != nil && opener != stackBottom && opener != bottom {
+			if opener.cc == cc && opener.canOpen {
+				if cc == '~' {
+					found = opener.origdelims == closer.origdelims
+				} else {
+					oddMatch := (closer.canOpen || opener.canClose) && closer.origdelims%3 != 0 && (opener.origdelims+closer.origdelims)%3 == 0
+					found = !oddMatch
+				}
+				if found {
+					break
+				}
+			}
+			opener = opener.previous
+		}
+
+		if !found {
+			openersBottom[kind] = closer.previous
+			next := closer.next
+			if !closer.canOpen {
+				ip.removeDelimiter(closer)
+			}
+			closer = next
+			continue
+		}
+
+		use, typ := 1, emphasis
+		switch {
+		case cc == '~':
+			use, typ = closer.numdelims, strikethrough
+		case closer.numdelims >= 2 && opener.numdelims >= 2:
+			use, typ = 2, strong
+		}
+		openerNode, closerNode := opener.node, closer.node
+		opener.numdelims -= use
+		closer.numdelims -= use
+		openerNode.literal = openerNode.literal[:len(openerNode.literal)-use]
+		closerNode.literal = closerNode.literal[:len(closerNode.literal)-use]
+
+		emph := &node{typ: typ}
+		for tmp := openerNode.next; tmp != nil && tmp != closerNode; {
+			next := tmp.next
+			emph.appendChild(tmp)
+			tmp = next
+		}
+		openerNode.insertAfter(emph)
+
+		// the delimiters between opener and closer are inside the emphasis now
+		if opener.next != closer {
+			opener.next = closer
+			closer.previous = opener
+		}
+		if opener.numdelims == 0 {
+			openerNode.unlink()
+			ip.removeDelimiter(opener)
+		}
+		if closer.numdelims == 0 {
+			closerNode.unlink()
+			next := closer.next
+			ip.removeDelimiter(closer)
+			closer = next
+		}
+	}
+
+	for ip.delimiters != nil && ip.delimiters != stackBottom {
+		ip.removeDelimiter(ip.delimiters)
+	}
+}
+
+func (ip *inlineParser) removeDelimiter(d *delimiter) {
+	if d.previous != nil {
+		d.previous.next = d.next
+	}
+	if d.next != nil {
+		d.next.previous = d.previous
+	} else {
+		ip.delimiters = d.previous
+	}
+}
+
+func (ip *inlineParser) parseOpenBracket(block *node) bool {
+	ip.pos++
+	n := textNode("[")
+	block.appendChild(n)
+	ip.addBracket(n, ip.pos, false)
+	return true
+}
+
+func (ip *inlineParser) parseBang(block *node) bool {
+	ip.pos++
+	if ip.peek() != '[' {
+		block.appendChild(textNode("!"))
+		return true
+	}
+	ip.pos++
+	n := textNode("![")
+	block.appendChild(n)
+	ip.addBracket(n, ip.pos, true)
+	return true
+}
+
+func (ip *inlineParser) addBracket(n *node, index int, image bool) {
+	if ip.brackets != nil {
+		ip.brackets.bracketAfter = true
+	}
+	ip.brackets = &bracket{
+		node:              n,
+		previous:          ip.brackets,
+		previousDelimiter: ip.delimiters,
+		index:             index,
+		image:             image,
+		active:            true,
+	}
+}
+
+// parseCloseBracket makes a link or an image of the inlines after the last `[` or
+// `![`, if it is followed by a destination or matches a link reference definition.
+func (ip *inlineParser) parseCloseBracket(block *node) bool {
+	closePos := ip.pos
+	ip.pos++
+	afterClose := ip.pos
+
+	opener := ip.brackets
+	if opener == nil {
+		block.appendChild(textNode("]"))
+		return true
+	}
+	if !opener.active {
+		block.appendChild(textNode("]"))
+		ip.brackets = opener.previous
+		return true
+	}
+
+	var dest, title string
+	matched := false
+	if ip.peek() == '(' {
+		ip.pos++
+		ip.spnl()
+		if d, ok := ip.parseLinkDestination(); ok {
+			ip.spnl()
+			if isUnicodeWhitespace(rune(ip.subject[ip.pos-1])) {
+				title, _ = ip.parseLinkTitle()
+			}
+			ip.spnl()
+			if ip.peek() == ')' {
+				ip.pos++
+				dest, matched = d, true
+			}
+		}
+		if !matched {
+			ip.pos = afterClose
+			title = ""
+		}
+	}
+
+	if !matched {
+		var label string
+		beforeLabel := ip.pos
+		n := ip.parseLinkLabel()
+		switch {
+		case n > 2:
+			label = ip.subject[beforeLabel+1 : beforeLabel+n-1]
+		case !opener.bracketAfter:
+			// `[foo][]` or `[foo]` use the text as the label
+			label = ip.subject[opener.index:closePos]
+		}
+		if n == 0 {
+			ip.pos = afterClose
+		}
+		if ref, ok := ip.refmap[normalizeLabel(label)]; ok && label != "" {
+			dest, title, matched = ref.dest, ref.title, true
+		}
+	}
+
+	if !matched {
+		ip.brackets = opener.previous
+		ip.pos = afterClose
+		block.appendChild(textNode("]"))
+		return true
+	}
+
+	typ := link
+	if opener.image {
+		typ = image
+	}
+	n := &node{typ: typ, dest: dest, title: title}
+	for tmp := opener.node.next; tmp != nil; {
+		next := tmp.next
+		n.appendChild(tmp)
+		tmp = next
+	}
+	block.appendChild(n)
+	ip.processEmphasis(opener.previousDelimiter)
+	ip.brackets = opener.previous
+	opener.node.unlink()
+
+	if !opener.image {
+		// no links in links
+		for o := ip.brackets; o != nil; o = o.previous {
+			if !o.image {
+				o.active = false
+			}
+		}
+	}
+	return true
+}
+
+// spnl skips spaces and tabs, and at most one newline.
+func (ip *inlineParser) spnl() {
+	for isSpaceOrTab(ip.peek()) {
+		ip.pos++
+	}
+	if ip.peek() == '\n' {
+		ip.pos++
+	}
+	for isSpaceOrTab(ip.peek()) {
+		ip.pos++
+	}
+}
+
+// parseLinkLabel consumes a link label, e.g. `[foo]`, and returns its length, or 0.
+func (ip *inlineParser) parseLinkLabel() int {
+	if ip.peek() != '[' {
+		return 0
+	}
+	for i := ip.pos + 1; i < len(ip.subject) && i-ip.pos <= 1000; i++ {
+		switch ip.subject[i] {
+		case '\\':
+			i++
+		case '[':
+			return 0
+		case ']':
+			n := i + 1 - ip.pos
+			ip.pos = i + 1
+			return n
+		}
+	}
+	return 0
+}
+
+// parseLinkDestination consumes the destination of a link, either `<...>` or
+// without spaces and with balanced parentheses.
+func (ip *inlineParser) parseLinkDestination() (string, bool) {
+	if ip.peek() == '<' {
+		for i := ip.pos + 1; i < len(ip.subject); i++ {
+			switch ip.subject[i] {
+			case '\\':
+				i++
+			case '\n', '<':
+				return "", false
+			case '>':
+				dest := ip.subject[ip.pos+1 : i]
+				ip.pos = i + 1
+				return normalizeURI(unescapeString(dest)), true
+			}
+		}
+		return "", false
+	}
+
+	start, parens := ip.pos, 0
+loop:
+	for ip.pos < len(ip.subject) {
+		switch c := ip.subject[ip.pos]; {
+		case c == '\\' && ip.pos+1 < len(ip.subject) && isASCIIPunct(ip.subject[ip.pos+1]):
+			ip.pos += 2
+		case c == '(':
+			parens++
+			ip.pos++
+		case c == ')':
+			if parens < 1 {
+				break loop
+			}
+			parens--
+			ip.pos++
+		case c <= ' ':
+			break loop
+		default:
+			ip.pos++
+		}
+	}
+	if (ip.pos == start && ip.peek() != ')') || parens != 0 {
+		ip.pos = start
+		return "", false
+	}
+	return normalizeURI(unescapeString(ip.subject[start:ip.pos])), true
+}
+
+// parseLinkTitle consumes a link title in `"..."`, `'...'` or `(...)`.
+func (ip *inlineParser) parseLinkTitle() (string, bool) {
+	var closing byte
+	switch ip.peek() {
+	case '"':
+		closing = '"'
+	case '\'':
+		closing = '\''
+	case '(':
+		closing = ')'
+	default:
+		return "", false
+	}
+	for i := ip.pos + 1; i < len(ip.subject); i++ {
+		switch c := ip.subject[i]; {
+		case c == '\\':
+			i++
+		case c == closing:
+			title := ip.subject[ip.pos+1 : i]
+			ip.pos = i + 1
+			return unescapeString(title), true
+		case closing == ')' && c == '(':
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// parseReference consumes a link reference definition at the start of s, and
+// returns its length, or 0.
+func (ip *inlineParser) parseReference(s string) int {
+	ip.subject, ip.pos = s, 0
+	n := ip.parseLinkLabel()
+	if n == 0 || ip.peek() != ':' {
+		return 0
+	}
+	label := s[1 : n-1]
+	ip.pos++
+	ip.spnl()
+	dest, ok := ip.parseLinkDestination()
+	if !ok {
+		return 0
+	}
+
+	beforeTitle := ip.pos
+	ip.spnl()
+	title, titleOK := "", false
+	if ip.pos != beforeTitle {
+		title, titleOK = ip.parseLinkTitle()
+	}
+	if !titleOK {
+		ip.pos = beforeTitle
+	}
+	if !ip.spaceAtEndOfLine() {
+		if !titleOK {
+			return 0
+		}
+		// the title is not at the end of the line; it is a definition without one
+		title, ip.pos = "", beforeTitle
+		if !ip.spaceAtEndOfLine() {
+			return 0
+		}
+	}
+
+	key := normalizeLabel(label)
+	if key == "" {
+		return 0
+	}
+	if _, ok := ip.refmap[key]; !ok {
+		ip.refmap[key] = linkRef{dest: dest, title: title}
+	}
+	return ip.pos
+}
+
+// spaceAtEndOfLine consumes spaces and tabs up to the end of the line, if there is
+// nothing else before it.
+func (ip *inlineParser) spaceAtEndOfLine() bool {
+	i := ip.pos
+	for i < len(ip.subject) && isSpaceOrTab(ip.subject[i]) {
+		i++
+	}
+	switch {
+	case i == len(ip.subject):
+		ip.pos = i
+		return true
+	case ip.subject[i] == '\n':
+		ip.pos = i + 1
+		return true
+	}
+	return false
+}
+
+var (
+	reEmailAutolink = regexp.MustCompile("^<([a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>")
+	reAutolink      = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9.+-]{1,31}:[^<>\x00-\x20]*)>`)
+	reEntity        = regexp.MustCompile(`^&(?:#[xX][a-fA-F0-9]{1,6}|#[0-9]{1,7}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+)
+
+func (ip *inlineParser) parseAutolink(block *node) bool {
+	rest := ip.subject[ip.pos:]
+	dest, label := "", ""
+	if m := reEmailAutolink.FindStringSubmatch(rest); m != nil {
+		dest, label = "mailto:"+m[1], m[1]
+		ip.pos += len(m[0])
+	} else if m := reAutolink.FindStringSubmatch(rest); m != nil {
+		dest, label = m[1], m[1]
+		ip.pos += len(m[0])
+	} else {
+		return false
+	}
+	n := &node{typ: link, dest: normalizeURI(dest)}
+	n.appendChild(textNode(label))
+	block.appendChild(n)
+	return true
+}
+
+func (ip *inlineParser) parseHTMLTag(block *node) bool {
+	if !ip.allowHTML {
+		return false
+	}
+	m := reHTMLTag.FindString(ip.subject[ip.pos:])
+	if m == "" {
+		return false
+	}
+	ip.pos += len(m)
+	block.appendChild(&node{typ: htmlInline, literal: m})
+	return true
+}
+
+func (ip *inlineParser) parseEntity(block *node) bool {
+	m := reEntity.FindString(ip.subject[ip.pos:])
+	if m == "" {
+		return false
+	}
+	decoded := html.UnescapeString(m)
+	if decoded == m {
+		return false // not a known entity
+	}
+	ip.pos += len(m)
+	block.appendChild(textNode(decoded))
+	return true
+}
+
+// normalizeLabel returns the key of a link label: case folded, with runs of
+// whitespace collapsed.
+func normalizeLabel(label string) string {
+	return strings.ToUpper(strings.ToLower(strings.Join(strings.Fields(label), " ")))
+}
+
+// normalizeURI percent encodes the characters of a URL that must be, leaving
+// existing percent encodings alone.
+func normalizeURI(uri string) string {
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(uri); i++ {
+		c := uri[i]
+		switch {
+		case c == '%' && i+2 < len(uri) && isHex(uri[i+1]) && isHex(uri[i+2]),
+			c < utf8.RuneSelf && (isASCIIAlnum(c) || strings.IndexByte(";/?:@&=+$,-_.!~*'()#", c) >= 0):
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(hex[c>>4])
+			sb.WriteByte(hex[c&15])
+		}
+	}
+	return sb.String()
+}
+
+// unescapeString replaces backslash escapes and character references in s.
+func unescapeString(s string) string {
+	if !strings.ContainsAny(s, `\&`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			sb.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		if c == '&' {
+			if m := reEntity.FindString(s[i:]); m != "" {
+				if decoded := html.UnescapeString(m); decoded != m {
+					sb.WriteString(decoded)
+					i += len(m) - 1
+					continue
+				}
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+func isASCIIPunct(c byte) bool {
+	return c != 0 && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isASCIIAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isUnicodeWhitespace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r' || unicode.Is(unicode.Zs, r)
+}
+
+func isUnicodePunct(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isASCIIPunct(byte(r))
+	}
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// html tags, comments and the like, from the CommonMark spec
+const (
+	tagName        = `[A-Za-z][A-Za-z0-9-]*`
+	attributeName  = `[a-zA-Z_:][a-zA-Z0-9:._-]*`
+	attributeValue = `(?:[^"'=<>` + "`" + `\x00-\x20]+|'[^']*'|"[^"]*")`
+	attribute      = `(?:\s+` + attributeName + `(?:\s*=\s*` + attributeValue + `)?)`
+	openTag        = `<` + tagName + attribute + `*\s*/?>`
+	closeTag       = `</` + tagName + `\s*>`
+	htmlComment    = `<!-->|<!--->|<!--[\s\S]*?-->`
+	processing     = `<\?[\s\S]*?\?>`
+	declaration    = `<![A-Za-z]+[^>]*>`
+	cdata          = `<!\[CDATA\[[\s\S]*?\]\]>`
+)
+
+var (
+	reHTMLTag = regexp.MustCompile(`^(?:` + openTag + `|` + closeTag + `|` + htmlComment + `|` + processing + `|` + declaration + `|` + cdata + `)`)
+
+	// htmlBlockOpen and htmlBlockClose are the start and end conditions of the
+	// seven kinds of html blocks
+	htmlBlockOpen = []*regexp.Regexp{
+		1: regexp.MustCompile(`(?i)^<(?:script|pre|textarea|style)(?:\s|>|$)`),
+		2: regexp.MustCompile(`^<!--`),
+		3: regexp.MustCompile(`^<[?]`),
+		4: regexp.MustCompile(`^<![A-Za-z]`),
+		5: regexp.MustCompile(`^<!\[CDATA\[`),
+		6: regexp.MustCompile(`(?i)^</?(?:address|article|aside|base|basefont|blockquote|body|caption|center|col|colgroup|dd|details|dialog|dir|div|dl|dt|fieldset|figcaption|figure|footer|form|frame|frameset|h[123456]|head|header|hr|html|iframe|legend|li|link|main|menu|menuitem|nav|noframes|ol|optgroup|option|p|param|search|section|summary|table|tbody|td|tfoot|th|thead|title|tr|track|ul)(?:\s|/?>|$)`),
+		7: regexp.MustCompile(`(?i)^(?:` + openTag + `|` + closeTag + `)\s*$`),
+	}
+	htmlBlockClose = []*regexp.Regexp{
+		1: regexp.MustCompile(`(?i)</(?:script|pre|textarea|style)>`),
+		2: regexp.MustCompile(`-->`),
+		3: regexp.MustCompile(`\?>`),
+		4: regexp.MustCompile(`>`),
+		5: regexp.MustCompile(`\]\]>`),
+	}
+)
@@ -0,0 +1,54 @@
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/choonkeat/dom-go/markdown"
+)
+
+func TestParseInlines(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		want  string
+	}{
+		{
+			name:  "emphasis",
+			given: "*foo**bar**baz* __strong__ a*b*c snake_case_name",
+			want:  "<p><em>foo<strong>bar</strong>baz</em> <strong>strong</strong> a<em>b</em>c snake_case_name</p>",
+		},
+		{
+			name:  "strikethrough",
+			given: "~~strike~~ ~one~ ~~~three~~~ ~~uneven~",
+			want:  "<p><del>strike</del> <del>one</del> ~~~three~~~ ~~uneven~</p>",
+		},
+		{
+			name:  "code spans",
+			given: "`code` `` a ` b `` `<b>`",
+			want:  "<p><code>code</code> <code>a ` b</code> <code>&lt;b&gt;</code></p>",
+		},
+		{
+			name:  "line breaks, escapes and entities",
+			given: "hard  \nbreak\\\nsoft\n\\*not em\\* &amp; &copy; &#65; &bogus;",
+			want:  "<p>hard<br/>break<br/>soft\n*not em* &amp; © A &amp;bogus;</p>",
+		},
+		{
+			name:  "links and images",
+			given: `[a](/x "t") [b](<my url>) [c *d*](/y) ![e *f*](g.png 'h') [not a link]`,
+			want:  `<p><a href="/x" title="t">a</a> <a href="my%20url">b</a> <a href="/y">c <em>d</em></a> <img src="g.png" alt="e f" title="h"/> [not a link]</p>`,
+		},
+		{
+			name:  "autolinks",
+			given: "<http://auto.link> <a@b.co> <not a link>",
+			want:  `<p><a href="http://auto.link">http://auto.link</a> <a href="mailto:a@b.co">a@b.co</a> &lt;not a link&gt;</p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(markdown.Parse(tt.given, markdown.Options{}).HTML())
+			if got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+		})
+	}
+}
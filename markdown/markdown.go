@@ -0,0 +1,272 @@
+// Package markdown converts Markdown into dom.Node trees.
+//
+// It follows CommonMark, with the GitHub Flavored Markdown tables, strikethrough
+// and task lists, and has no dependencies besides the standard library.
+//
+// Example:
+//
+//	page := dom.Article(dom.Attrs(),
+//		markdown.Parse(source, markdown.Options{}),
+//	)
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// Kind is the Markdown construct that an element is made from.
+type Kind int
+
+// Markdown constructs.
+const (
+	Paragraph     Kind = iota // `<p>`
+	Heading                   // `<h1>` to `<h6>`
+	ThematicBreak             // `<hr>`
+	BlockQuote                // `<blockquote>`
+	List                      // `<ul>` or `<ol>`
+	ListItem                  // `<li>`, with a checkbox for task list items
+	CodeBlock                 // `<pre>` with a `<code>`
+	Table                     // `<table>`
+	TableRow                  // `<tr>`
+	TableCell                 // `<th>` or `<td>`
+	Emphasis                  // `<em>`
+	Strong                    // `<strong>`
+	Strikethrough             // `<del>`
+	Code                      // `<code>`
+	Link                      // `<a>`
+	Image                     // `<img>`
+	LineBreak                 // `<br>`
+)
+
+// Hook is called with every element made from Markdown, and returns the element to
+// use instead, e.g. to add a class to every table, or rewrite the URL of links.
+type Hook func(kind Kind, n dom.Node) dom.Node
+
+// Options configures Parse. The zero value is ready to use.
+type Options struct {
+	// AllowHTML keeps the raw html in the Markdown. By default it is written out
+	// as text, and links to `javascript:` and other unsafe URLs are left empty.
+	AllowHTML bool
+
+	// Hook, if set, is called with every element made from Markdown. Elements are
+	// passed to Hook after their children.
+	Hook Hook
+}
+
+// Parse returns the Markdown in source as a fragment of elements.
+func Parse(source string, opts Options) dom.Node {
+	p := newBlockParser(opts)
+	doc := p.parse(source)
+	r := &renderer{opts: opts, inlines: &inlineParser{refmap: p.refmap, allowHTML: opts.AllowHTML}}
+	return dom.Node{Children: r.blocks(doc, false)}
+}
+
+// renderer turns the parsed Markdown into elements.
+type renderer struct {
+	opts    Options
+	inlines *inlineParser
+}
+
+func (r *renderer) hook(kind Kind, n dom.Node) dom.Node {
+	if r.opts.Hook == nil {
+		return n
+	}
+	return r.opts.Hook(kind, n)
+}
+
+// blocks returns the children of a container block. Paragraphs in tight lists are
+// written without `<p>`.
+func (r *renderer) blocks(parent *node, tight bool) []dom.Node {
+	var nodes []dom.Node
+	for c := parent.first; c != nil; c = c.next {
+		if tight && c.typ == paragraph {
+			nodes = append(nodes, r.inline(c.literal)...)
+			continue
+		}
+		nodes = append(nodes, r.block(c, tight))
+	}
+	return nodes
+}
+
+func (r *renderer) block(n *node, tight bool) dom.Node {
+	switch n.typ {
+	case paragraph:
+		return r.hook(Paragraph, dom.P(dom.Attrs(), r.inline(n.literal)...))
+
+	case heading:
+		name := string([]byte{'h', byte('0' + n.level)})
+		return r.hook(Heading, dom.Element(name, dom.Attrs(), r.inline(n.literal)...))
+
+	case thematicBreak:
+		return r.hook(ThematicBreak, dom.Hr(dom.Attrs()))
+
+	case blockQuote:
+		return r.hook(BlockQuote, dom.Blockquote(dom.Attrs(), r.blocks(n, false)...))
+
+	case list:
+		var items []dom.Node
+		for item := n.first; item != nil; item = item.next {
+			items = append(items, r.block(item, n.tight))
+		}
+		if n.list.ordered {
+			attrs := dom.Attrs()
+			if n.list.start != 1 {
+				attrs = dom.Attrs("start", strconv.Itoa(n.list.start))
+			}
+			return r.hook(List, dom.Ol(attrs, items...))
+		}
+		return r.hook(List, dom.Ul(dom.Attrs(), items...))
+
+	case item:
+		children := r.blocks(n, tight)
+		if n.task != noTask {
+			attrs := dom.Attrs("type", "checkbox", "disabled", "")
+			if n.task == checkedTask {
+				attrs = dom.Attrs("type", "checkbox", "disabled", "", "checked", "")
+			}
+			children = append([]dom.Node{dom.Input(attrs), dom.InnerText(" ")}, children...)
+		}
+		return r.hook(ListItem, dom.Li(dom.Attrs(), children...))
+
+	case codeBlock:
+		attrs := dom.Attrs()
+		if fields := strings.Fields(n.info); len(fields) > 0 {
+			attrs = dom.Attrs("class", "language-"+fields[0])
+		}
+		var code []dom.Node
+		if n.literal != "" {
+			code = []dom.Node{dom.InnerText(n.literal)}
+		}
+		return r.hook(CodeBlock, dom.Pre(dom.Attrs(), dom.Code(attrs, code...)))
+
+	case htmlBlock:
+		return dom.InnerHTML(n.literal)
+
+	case table:
+		return r.table(n)
+	}
+	return dom.Node{}
+}
+
+func (r *renderer) table(n *node) dom.Node {
+	row := func(cells []string, name string) dom.Node {
+		nodes := make([]dom.Node, len(n.aligns))
+		for i, align := range n.aligns {
+			text := ""
+			if i < len(cells) {
+				text = cells[i]
+			}
+			attrs := dom.Attrs()
+			if align != "" {
+				attrs = dom.Attrs("align", align)
+			}
+			nodes[i] = r.hook(TableCell, dom.Element(name, attrs, r.inline(text)...))
+		}
+		return r.hook(TableRow, dom.Tr(dom.Attrs(), nodes...))
+	}
+
+	children := []dom.Node{dom.Thead(dom.Attrs(), row(n.rows[0], "th"))}
+	if len(n.rows) > 1 {
+		rows := make([]dom.Node, 0, len(n.rows)-1)
+		for _, cells := range n.rows[1:] {
+			rows = append(rows, row(cells, "td"))
+		}
+		children = append(children, dom.Tbody(dom.Attrs(), rows...))
+	}
+	return r.hook(Table, dom.Table(dom.Attrs(), children...))
+}
+
+// inline returns the inline content of a paragraph, heading or table cell.
+func (r *renderer) inline(s string) []dom.Node {
+	container := &node{}
+	r.inlines.parse(container, s)
+	return r.inlineChildren(container)
+}
+
+func (r *renderer) inlineChildren(parent *node) []dom.Node {
+	var nodes []dom.Node
+	var sb strings.Builder // adjacent text is written as one
+	flush := func() {
+		if sb.Len() > 0 {
+			nodes = append(nodes, dom.InnerText(sb.String()))
+			sb.Reset()
+		}
+	}
+	for c := parent.first; c != nil; c = c.next {
+		switch c.typ {
+		case text:
+			sb.WriteString(c.literal)
+			continue
+		case softBreak:
+			sb.WriteString("\n")
+			continue
+		}
+		flush()
+		nodes = append(nodes, r.inlineNode(c))
+	}
+	flush()
+	return nodes
+}
+
+func (r *renderer) inlineNode(n *node) dom.Node {
+	switch n.typ {
+	case hardBreak:
+		return r.hook(LineBreak, dom.Br(dom.Attrs()))
+	case emphasis:
+		return r.hook(Emphasis, dom.Em(dom.Attrs(), r.inlineChildren(n)...))
+	case strong:
+		return r.hook(Strong, dom.Strong(dom.Attrs(), r.inlineChildren(n)...))
+	case strikethrough:
+		return r.hook(Strikethrough, dom.Del(dom.Attrs(), r.inlineChildren(n)...))
+	case code:
+		return r.hook(Code, dom.Code(dom.Attrs(), dom.InnerText(n.literal)))
+	case htmlInline:
+		return dom.InnerHTML(n.literal)
+	case link:
+		attrs := dom.Attrs("href", r.safeURL(n.dest, false))
+		if n.title != "" {
+			attrs = append(attrs, dom.Attrs("title", n.title)...)
+		}
+		return r.hook(Link, dom.A(attrs, r.inlineChildren(n)...))
+	case image:
+		attrs := dom.Attrs("src", r.safeURL(n.dest, true), "alt", plainText(n))
+		if n.title != "" {
+			attrs = append(attrs, dom.Attrs("title", n.title)...)
+		}
+		return r.hook(Image, dom.Img(attrs))
+	}
+	return dom.Node{}
+}
+
+var (
+	unsafeURL    = regexp.MustCompile(`(?i)^(?:javascript|vbscript|file|data):`)
+	safeImageURL = regexp.MustCompile(`(?i)^data:image/(?:png|gif|jpeg|webp);`)
+)
+
+// safeURL returns url, or "" if it could run scripts and raw html is not allowed.
+func (r *renderer) safeURL(url string, image bool) string {
+	if r.opts.AllowHTML || !unsafeURL.MatchString(url) || (image && safeImageURL.MatchString(url)) {
+		return url
+	}
+	return ""
+}
+
+// plainText returns the text of inline content, e.g. for the alt text of an image.
+func plainText(n *node) string {
+	var sb strings.Builder
+	for c := n.first; c != nil; c = c.next {
+		switch c.typ {
+		case text, code, htmlInline:
+			sb.WriteString(c.literal)
+		case softBreak, hardBreak:
+			sb.WriteString("\n")
+		default:
+			sb.WriteString(plainText(c))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,97 @@
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/markdown"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		opts  markdown.Options
+		want  string
+	}{
+		{
+			name:  "empty",
+			given: "",
+			want:  "",
+		},
+		{
+			name:  "document",
+			given: "# Hello *world*\n\nSome **bold** and _em_ text\nwith a [link](http://x.com \"T\") and ![*img*](a.png).\n",
+			want:  `<h1>Hello <em>world</em></h1><p>Some <strong>bold</strong> and <em>em</em> text` + "\n" + `with a <a href="http://x.com" title="T">link</a> and <img src="a.png" alt="img"/>.</p>`,
+		},
+		{
+			name:  "raw html is text by default",
+			given: "<div>\n*hi*\n</div>\n\nx <span>y</span>",
+			want:  "<p>&lt;div&gt;\n<em>hi</em>\n&lt;/div&gt;</p><p>x &lt;span&gt;y&lt;/span&gt;</p>",
+		},
+		{
+			name:  "raw html allowed",
+			given: "<div>\n*hi*\n</div>\n\nx <span>y</span>",
+			opts:  markdown.Options{AllowHTML: true},
+			want:  "<div>\n*hi*\n</div><p>x <span>y</span></p>",
+		},
+		{
+			name:  "unsafe urls are emptied",
+			given: "[x](javascript:alert(1)) ![y](data:image/png;base64,AA) [z](data:text/html,x) [v](VBScript:x)",
+			want:  `<p><a href="">x</a> <img src="data:image/png;base64,AA" alt="y"/> <a href="">z</a> <a href="">v</a></p>`,
+		},
+		{
+			name:  "unsafe urls kept when raw html allowed",
+			given: "[x](javascript:alert(1))",
+			opts:  markdown.Options{AllowHTML: true},
+			want:  `<p><a href="javascript:alert(1)">x</a></p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(markdown.Parse(tt.given, tt.opts).HTML())
+			if got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHook(t *testing.T) {
+	var kinds []markdown.Kind
+	opts := markdown.Options{
+		Hook: func(kind markdown.Kind, n dom.Node) dom.Node {
+			kinds = append(kinds, kind)
+			switch kind {
+			case markdown.Table:
+				return n.SetAttr("class", "table")
+			case markdown.Link:
+				href, _ := n.Attr("href")
+				return n.SetAttr("href", "/out?to="+href)
+			case markdown.Image:
+				return dom.Figure(dom.Attrs(), n)
+			}
+			return n
+		},
+	}
+	given := "[a](http://x.com) ![b](b.png)\n\n| c |\n| - |\n| d |\n"
+	got := string(markdown.Parse(given, opts).HTML())
+	want := `<p><a href="/out?to=http://x.com">a</a> <figure><img src="b.png" alt="b"/></figure></p>` +
+		`<table class="table"><thead><tr><th>c</th></tr></thead><tbody><tr><td>d</td></tr></tbody></table>`
+	if got != want {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+
+	wantKinds := []markdown.Kind{
+		markdown.Link, markdown.Image, markdown.Paragraph,
+		markdown.TableCell, markdown.TableRow, markdown.TableCell, markdown.TableRow, markdown.Table,
+	}
+	if len(kinds) != len(wantKinds) {
+		t.Fatalf("\ngot      %v\nbut want %v", kinds, wantKinds)
+	}
+	for i := range kinds {
+		if kinds[i] != wantKinds[i] {
+			t.Fatalf("\ngot      %v\nbut want %v", kinds, wantKinds)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package dom_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		given dom.Node
+		want  string
+	}{
+		{
+			name: "headings and paragraphs",
+			given: dom.Article(dom.Attrs(),
+				dom.H1(dom.Attrs(), dom.InnerText("Getting started")),
+				dom.P(dom.Attrs(),
+					dom.InnerText("Install "),
+					dom.Strong(dom.Attrs(), dom.InnerText(" dom-go ")),
+					dom.InnerText("with "),
+					dom.Code(dom.Attrs(), dom.InnerText("go get")),
+					dom.InnerText(", it's "),
+					dom.Em(dom.Attrs(), dom.InnerText("easy")),
+					dom.InnerText(" and "),
+					dom.Del(dom.Attrs(), dom.InnerText("hard")),
+					dom.InnerText("."),
+				),
+				dom.Div(dom.Attrs(),
+					dom.H3(dom.Attrs(), dom.InnerText("Notes")),
+					dom.P(dom.Attrs(), dom.InnerText("Line one"), dom.Br(dom.Attrs()), dom.InnerText("line two")),
+				),
+				dom.Hr(dom.Attrs()),
+				dom.Script(dom.Attrs(), dom.InnerText("alert(1)")),
+			),
+			want: strings.Join([]string{
+				"# Getting started",
+				"",
+				"Install **dom-go** with `go get`, it's *easy* and ~~hard~~.",
+				"",
+				"### Notes",
+				"",
+				`Line one\`,
+				"line two",
+				"",
+				"---",
+			}, "\n"),
+		},
+		{
+			name: "escaping",
+			given: dom.Div(dom.Attrs(),
+				dom.P(dom.Attrs(), dom.InnerText("# not a heading, 2*3 [x] <b> a_b &amp; & |")),
+				dom.P(dom.Attrs(), dom.InnerText("1. not a list")),
+				dom.P(dom.Attrs(), dom.Code(dom.Attrs(), dom.InnerText("a `tick`"))),
+			),
+			want: strings.Join([]string{
+				`\# not a heading, 2\*3 \[x\] \<b\> a\_b \&amp; & \|`,
+				"",
+				`1\. not a list`,
+				"",
+				"`` a `tick` ``",
+			}, "\n"),
+		},
+		{
+			name: "links and images",
+			given: dom.P(dom.Attrs(),
+				dom.A(dom.Attrs("href", "https://example.com/a b", "title", `Say "hi"`), dom.InnerText("Example")),
+				dom.InnerText(" "),
+				dom.A(dom.Attrs("href", "https://example.com"), dom.InnerText("https://example.com")),
+				dom.InnerText(" "),
+				dom.A(dom.Attrs("href", "mailto:hi@example.com"), dom.InnerText("hi@example.com")),
+				dom.InnerText(" "),
+				dom.Img(dom.Attrs("src", "logo.png", "alt", "The [logo]")),
+			),
+			want: `[Example](https://example.com/a%20b "Say \"hi\"") <https://example.com> <hi@example.com> ![The \[logo\]](logo.png)`,
+		},
+		{
+			name: "code blocks",
+			given: dom.Pre(dom.Attrs(),
+				dom.Code(dom.Attrs("class", "language-go"), dom.InnerText("fmt.Println(\"```\")\n")),
+			),
+			want: "````go\nfmt.Println(\"```\")\n````",
+		},
+		{
+			name: "lists and quotes",
+			given: dom.Div(dom.Attrs(),
+				dom.Ul(dom.Attrs(),
+					dom.Li(dom.Attrs(), dom.InnerText("Fruit"),
+						dom.Ol(dom.Attrs("start", "3"),
+							dom.Li(dom.Attrs(), dom.InnerText("Apple")),
+							dom.Li(dom.Attrs(), dom.InnerText("Banana")),
+						),
+					),
+					dom.Li(dom.Attrs(), dom.InnerText("- Vegetables")),
+				),
+				dom.Blockquote(dom.Attrs(),
+					dom.P(dom.Attrs(), dom.InnerText("Quote")),
+					dom.P(dom.Attrs(), dom.InnerText("Author")),
+				),
+			),
+			want: strings.Join([]string{
+				"- Fruit",
+				"  3. Apple",
+				"  4. Banana",
+				`- \- Vegetables`,
+				"",
+				"> Quote",
+				">",
+				"> Author",
+			}, "\n"),
+		},
+		{
+			name: "tables",
+			given: dom.Table(dom.Attrs(),
+				dom.Thead(dom.Attrs(),
+					dom.Tr(dom.Attrs(),
+						dom.Th(dom.Attrs(), dom.InnerText("Item")),
+						dom.Th(dom.Attrs("align", "right"), dom.InnerText("Price")),
+						dom.Th(dom.Attrs("style", "text-align: center"), dom.InnerText("Qty")),
+					),
+				),
+				dom.Tbody(dom.Attrs(),
+					dom.Tr(dom.Attrs(),
+						dom.Td(dom.Attrs(), dom.InnerText("Coffee | tea")),
+						dom.Td(dom.Attrs(), dom.InnerText("3.50")),
+						dom.Td(dom.Attrs(), dom.InnerText("1"), dom.Br(dom.Attrs()), dom.InnerText("2")),
+					),
+				),
+			),
+			want: strings.Join([]string{
+				"| Item          | Price | Qty    |",
+				"| ------------- | ----: | :----: |",
+				`| Coffee \| tea | 3.50  | 1<br>2 |`,
+			}, "\n"),
+		},
+		{
+			name: "raw html fallback",
+			given: dom.Div(dom.Attrs(),
+				dom.P(dom.Attrs(), dom.InnerText("E = mc"), dom.Sup(dom.Attrs(), dom.InnerText("2"))),
+				dom.Details(dom.Attrs(), dom.Summary(dom.Attrs(), dom.InnerText("More"))),
+				dom.Table(dom.Attrs(), dom.Tr(dom.Attrs(), dom.Td(dom.Attrs(), dom.InnerText("no header")))),
+			),
+			want: strings.Join([]string{
+				"E = mc<sup>2</sup>",
+				"",
+				"<details><summary>More</summary></details>",
+				"",
+				"<table><tr><td>no header</td></tr></table>",
+			}, "\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.given.Markdown(); got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package dom
+
+// Middleware inspects an element, i.e. a Node with a Name, while it is being rendered
+// by a Renderer, and returns the Node to write in its place. The children of the
+// returned Node are rendered, and passed through the middleware, as usual.
+//
+// The given Node is shared with the tree being rendered: return a changed copy
+// instead of modifying its Attributes or Children in place, e.g. with Node.SetAttr.
+//
+// Example:
+//
+//	lazyImages := func(e dom.Node) dom.Node {
+//		if e.Name != "img" {
+//			return e
+//		}
+//		return e.SetAttr("loading", "lazy")
+//	}
+//	r := dom.Renderer{Middleware: []dom.Middleware{lazyImages}}
+type Middleware func(Node) Node
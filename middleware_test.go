@@ -0,0 +1,78 @@
+package dom_test
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+)
+
+func lazyImages(e dom.Node) dom.Node {
+	if e.Name != "img" {
+		return e
+	}
+	return e.SetAttr("loading", "lazy")
+}
+
+func noopenerLinks(e dom.Node) dom.Node {
+	if href, _ := e.Attr("href"); e.Name != "a" || !strings.HasPrefix(href, "http") {
+		return e
+	}
+	return e.SetAttr("rel", "noopener")
+}
+
+func csrfForms(token string) dom.Middleware {
+	return func(e dom.Node) dom.Node {
+		if method, _ := e.Attr("method"); e.Name != "form" || !strings.EqualFold(method, "post") {
+			return e
+		}
+		e.Children = append([]dom.Node{
+			dom.Input(dom.Attrs("type", "hidden", "name", "csrf", "value", token)),
+		}, e.Children...)
+		return e
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	given := dom.Div(dom.Attrs(),
+		dom.Img(dom.Attrs("src", "a.png", "loading", "eager")),
+		dom.P(dom.Attrs(),
+			dom.A(dom.Attrs("href", "https://example.com"), dom.Img(dom.Attrs("src", "b.png"))),
+			dom.A(dom.Attrs("href", "/local"), dom.InnerText("local")),
+		),
+		dom.Form(dom.Attrs("method", "POST"), dom.Button(dom.Attrs(), dom.InnerText("Save"))),
+		dom.Form(dom.Attrs("method", "get")),
+	)
+	want := template.HTML(`<div>` +
+		`<img src="a.png" loading="lazy"/>` +
+		`<p><a href="https://example.com" rel="noopener"><img src="b.png" loading="lazy"/></a><a href="/local">local</a></p>` +
+		`<form method="POST"><input type="hidden" name="csrf" value="t&lt;k&gt;"/><button>Save</button></form>` +
+		`<form method="get"></form>` +
+		`</div>`)
+	oldHTML := given.HTML()
+
+	for _, renderer := range []dom.Renderer{
+		{},
+		{Workers: 2, Threshold: 1},
+	} {
+		renderer.Middleware = []dom.Middleware{lazyImages, noopenerLinks, csrfForms("t<k>")}
+		if got := renderer.HTML(given); got != want {
+			t.Errorf("\ngot      %q\nbut want %q", got, want)
+		}
+		if given.HTML() != oldHTML {
+			t.Errorf("Middleware modified the given node")
+		}
+	}
+}
+
+func ExampleMiddleware() {
+	r := dom.Renderer{Middleware: []dom.Middleware{lazyImages}}
+	fmt.Println(
+		r.HTML(dom.P(dom.Attrs(), dom.Img(dom.Attrs("src", "cat.png")))),
+	)
+	// Output: <p><img src="cat.png" loading="lazy"/></p>
+}
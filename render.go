@@ -0,0 +1,205 @@
+package dom
+
+import (
+	"html/template"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultThreshold is the Renderer.Threshold used when none is given.
+const DefaultThreshold = 64
+
+// Renderer renders a Node like Node.HTML does, but with opt-in behaviour that the
+// plain Node.HTML does not pay for. The zero value renders exactly like Node.HTML.
+//
+// Example:
+//
+//	r := dom.Renderer{Workers: runtime.GOMAXPROCS(0)}
+//	html := r.HTML(dom.Table(dom.Attrs(), rows...))
+type Renderer struct {
+	// Workers is the maximum number of goroutines rendering sibling subtrees
+	// concurrently, in addition to the calling goroutine. Zero renders sequentially.
+	//
+	// Each subtree is rendered into its own buffer and the buffers are stitched
+	// together in order, so the output is byte-identical to Node.HTML.
+	Workers int
+
+	// Threshold is the minimum number of nodes handed to a worker at once, either a
+	// subtree, or a run of smaller sibling subtrees such as the rows of a table;
+	// less is cheaper to render in place. Zero means DefaultThreshold.
+	Threshold int
+
+	// Tracer, if set, is told when the rendering of every named node, see Component,
+	// starts and ends. It must be safe for concurrent use when Workers is set.
+	Tracer Tracer
+
+	// Middleware are applied in order to every element before it is written. They
+	// must be safe for concurrent use when Workers is set.
+	Middleware []Middleware
+}
+
+// HTML returns the HTML representation of the node.
+func (r *Renderer) HTML(e Node) template.HTML {
+	if r.Workers <= 0 && r.Tracer == nil && len(r.Middleware) == 0 {
+		return e.HTML()
+	}
+	threshold := r.Threshold
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	rs := &renderState{
+		render:     renders.Add(1),
+		threshold:  threshold,
+		tracer:     r.Tracer,
+		middleware: r.Middleware,
+	}
+	if r.Workers > 0 {
+		rs.workers = make(chan struct{}, r.Workers)
+	}
+	var sb strings.Builder
+	rs.build(&sb, e, nil)
+	return template.HTML(sb.String())
+}
+
+// renders counts Renderer.HTML calls, for TraceEvent.Render.
+var renders atomic.Uint64
+
+// renderState is shared by every goroutine taking part in one Renderer.HTML call.
+type renderState struct {
+	render     uint64        // the TraceEvent.Render of this call
+	workers    chan struct{} // semaphore bounding the number of worker goroutines; nil when sequential
+	threshold  int
+	tracer     Tracer
+	middleware []Middleware
+}
+
+// subtree is a run of children, from the one it is kept for up to end, rendered by
+// a worker into its own buffer.
+type subtree struct {
+	sb   strings.Builder
+	end  int
+	done chan struct{}
+}
+
+// build writes e into sb. path is the child indices from the root to e, and is
+// only maintained when there is a tracer to report it to.
+func (rs *renderState) build(sb *strings.Builder, e Node, path []int) {
+	if rs.tracer != nil && e.Component != "" {
+		span := TraceEvent{Component: e.Component, Render: rs.render, Path: append([]int{}, path...)}
+		rs.tracer.Start(span)
+		start, offset := time.Now(), sb.Len()
+		defer func() {
+			span.Bytes = sb.Len() - offset
+			span.Duration = time.Since(start)
+			rs.tracer.End(span)
+		}()
+	}
+
+	if e.Name != "" {
+		for _, m := range rs.middleware {
+			e = m(e)
+		}
+	}
+
+	if e.Name == "" {
+		rs.buildChildren(sb, e, path)
+		return
+	}
+
+	tagName := template.HTMLEscapeString(e.Name)
+	sb.WriteString("<")
+	sb.WriteString(tagName)
+	for _, attr := range e.Attributes {
+		sb.WriteString(" ")
+		attr.buildHTML(sb)
+	}
+	if isVoidElement(e.Name) {
+		sb.WriteString("/>")
+		return
+	}
+	sb.WriteString(">")
+	rs.buildChildren(sb, e, path)
+	sb.WriteString("</" + tagName + ">")
+}
+
+func (rs *renderState) buildChildren(sb *strings.Builder, e Node, path []int) {
+	if e.InnerHTML != "" {
+		sb.WriteString(string(e.InnerHTML))
+		return
+	} else if e.InnerText != "" {
+		sb.WriteString(template.HTMLEscapeString(e.InnerText))
+		return
+	}
+
+	// hand runs of children with enough nodes to idle workers first, so that
+	// they run while this goroutine renders the rest of the siblings in order
+	var subtrees []*subtree
+	start, size := 0, 0
+	for i, child := range e.Children {
+		if rs.workers == nil {
+			break
+		}
+		size += countNodes(child, rs.threshold-size)
+		if size < rs.threshold {
+			continue
+		}
+		first := start
+		start, size = i+1, 0
+		select {
+		case rs.workers <- struct{}{}:
+		default:
+			// no idle worker; render them in place. never block waiting for one,
+			// since workers themselves get here for their own children
+			continue
+		}
+		if subtrees == nil {
+			subtrees = make([]*subtree, len(e.Children))
+		}
+		st := &subtree{end: i + 1, done: make(chan struct{})}
+		subtrees[first] = st
+		go func(first int) {
+			defer func() { <-rs.workers }()
+			defer close(st.done)
+			for j := first; j < st.end; j++ {
+				rs.build(&st.sb, e.Children[j], rs.childPath(path, j, true))
+			}
+		}(first)
+	}
+
+	for i := 0; i < len(e.Children); i++ {
+		if subtrees != nil && subtrees[i] != nil {
+			<-subtrees[i].done
+			sb.WriteString(subtrees[i].sb.String())
+			i = subtrees[i].end - 1
+			continue
+		}
+		rs.build(sb, e.Children[i], rs.childPath(path, i, false))
+	}
+}
+
+// childPath returns the path of the i-th child of the node at path. The backing
+// array of path is reused between siblings, unless the child is rendered by
+// another goroutine.
+func (rs *renderState) childPath(path []int, i int, shared bool) []int {
+	if rs.tracer == nil {
+		return nil
+	}
+	if shared {
+		return append(append(make([]int, 0, len(path)+1), path...), i)
+	}
+	return append(path, i)
+}
+
+// countNodes returns the number of nodes in the tree rooted at e, but stops
+// counting once limit is reached.
+func countNodes(e Node, limit int) int {
+	n := 1
+	for _, child := range e.Children {
+		if n >= limit {
+			break
+		}
+		n += countNodes(child, limit-n)
+	}
+	return n
+}
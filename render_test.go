@@ -0,0 +1,98 @@
+package dom_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/choonkeat/dom-go"
+)
+
+func tableRows(n int) dom.Node {
+	rows := make([]dom.Node, 0, n)
+	for i := 0; i < n; i++ {
+		rows = append(rows, dom.Tr(
+			dom.Attrs("class", "row"),
+			dom.Td(dom.Attrs(), dom.InnerText(fmt.Sprintf("<%d>", i))),
+			dom.Td(dom.Attrs("class", "name"), dom.InnerText(`Tom & "Jerry"`)),
+			dom.Td(dom.Attrs(), dom.Br(dom.Attrs()), dom.InnerHTML("<em>trusted</em>")),
+		))
+	}
+	return dom.Table(dom.Attrs("id", "results"),
+		dom.Thead(dom.Attrs(), dom.Tr(dom.Attrs(), dom.Th(dom.Attrs(), dom.InnerText("#")))),
+		dom.Tbody(dom.Attrs(), rows...),
+	)
+}
+
+func TestRendererHTML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		renderer dom.Renderer
+		given    dom.Node
+	}{
+		{
+			name:     "zero value",
+			renderer: dom.Renderer{},
+			given:    tableRows(10),
+		},
+		{
+			name:     "every subtree to a worker",
+			renderer: dom.Renderer{Workers: 8, Threshold: 1},
+			given:    tableRows(100),
+		},
+		{
+			name:     "fewer workers than subtrees",
+			renderer: dom.Renderer{Workers: 2, Threshold: 3},
+			given:    tableRows(100),
+		},
+		{
+			name:     "below threshold",
+			renderer: dom.Renderer{Workers: 4},
+			given:    tableRows(3),
+		},
+		{
+			name:     "runs of small rows to workers",
+			renderer: dom.Renderer{Workers: 3, Threshold: 20},
+			given:    tableRows(100),
+		},
+		{
+			name:     "text only",
+			renderer: dom.Renderer{Workers: 4, Threshold: 1},
+			given:    dom.InnerText("<oops>"),
+		},
+	}
+	for _, tt := range tests {
+		if got, want := tt.renderer.HTML(tt.given), tt.given.HTML(); got != want {
+			t.Errorf("%s:\ngot      %q\nbut want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestRendererSmallSiblings(t *testing.T) {
+	t.Parallel()
+
+	// every row is below the threshold, but runs of rows are not
+	var running, most atomic.Int32
+	renderer := dom.Renderer{Workers: 4, Threshold: 16, Middleware: []dom.Middleware{
+		func(e dom.Node) dom.Node {
+			if e.Name == "tr" {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+				}
+				time.Sleep(time.Millisecond)
+			}
+			return e
+		},
+	}}
+	given := tableRows(40)
+	if got, want := renderer.HTML(given), given.HTML(); got != want {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+	if most.Load() < 2 {
+		t.Errorf("rows were rendered one at a time")
+	}
+}
@@ -0,0 +1,160 @@
+// Package sse streams rendered elements to the browser as Server-Sent Events, e.g.
+// notification badges, progress updates and chat messages.
+//
+// Example:
+//
+//	func progress(w http.ResponseWriter, r *http.Request) {
+//		stream, err := sse.NewWriter(w, r)
+//		if err != nil {
+//			http.Error(w, err.Error(), http.StatusInternalServerError)
+//			return
+//		}
+//		for update := range updates {
+//			if err := stream.Send(sse.Event{Name: "progress", Data: render(update)}); err != nil {
+//				return // the client is gone
+//			}
+//		}
+//	}
+//
+// and in the page, `new EventSource("/progress")`.
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/choonkeat/dom-go"
+)
+
+// ErrStreamingUnsupported is returned by NewWriter for a ResponseWriter that cannot
+// be flushed.
+var ErrStreamingUnsupported = errors.New("sse: streaming unsupported")
+
+// Event is a Server-Sent Event.
+type Event struct {
+	// Name is the type of the event, for `addEventListener`; by default it is
+	// "message", for `onmessage`.
+	Name string
+
+	// ID is the id of the event, sent back by the browser in the `Last-Event-ID`
+	// header when it reconnects.
+	ID string
+
+	// Retry, if positive, is how long the browser waits before reconnecting.
+	Retry time.Duration
+
+	// Data is rendered as the data of the event. Raw text, e.g. JSON, can be sent as
+	// dom.InnerHTML. An empty Data is still sent, and dispatched with "" as its data.
+	Data dom.Node
+}
+
+// Writer writes events to the response of an event stream.
+type Writer struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	ctx context.Context
+}
+
+// NewWriter starts the event stream of the response to r, and returns a Writer of
+// its events.
+func NewWriter(w http.ResponseWriter, r *http.Request) (*Writer, error) {
+	if !canFlush(w) {
+		return nil, ErrStreamingUnsupported
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx would buffer the stream otherwise
+	w.WriteHeader(http.StatusOK)
+	sw := &Writer{w: w, rc: http.NewResponseController(w), ctx: r.Context()}
+	if err := sw.rc.Flush(); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+// canFlush reports whether w, or a ResponseWriter it wraps, is an http.Flusher.
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch rw := w.(type) {
+		case http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// Done is closed when the client disconnects.
+func (sw *Writer) Done() <-chan struct{} {
+	return sw.ctx.Done()
+}
+
+// Send writes the event and flushes it to the client. It returns an error if the
+// client has disconnected, or the name or id has a line break.
+func (sw *Writer) Send(ev Event) error {
+	if err := sw.ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(ev.Name, "\r\n") {
+		return fmt.Errorf("sse: event name %q has a line break", ev.Name)
+	}
+	if strings.ContainsAny(ev.ID, "\r\n\x00") {
+		return fmt.Errorf("sse: event id %q has a line break or NUL", ev.ID)
+	}
+
+	var sb strings.Builder
+	if ev.Name != "" {
+		sb.WriteString("event: " + ev.Name + "\n")
+	}
+	if ev.ID != "" {
+		sb.WriteString("id: " + ev.ID + "\n")
+	}
+	if ev.Retry > 0 {
+		sb.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+	writeData(&sb, string(ev.Data.HTML()))
+	sb.WriteString("\n")
+	return sw.write(sb.String())
+}
+
+// Comment writes a comment, which the browser ignores, e.g. to keep the connection
+// open through proxies that close idle ones.
+func (sw *Writer) Comment(text string) error {
+	if err := sw.ctx.Err(); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, line := range splitLines(text) {
+		sb.WriteString(": " + line + "\n")
+	}
+	sb.WriteString("\n")
+	return sw.write(sb.String())
+}
+
+func (sw *Writer) write(s string) error {
+	if _, err := fmt.Fprint(sw.w, s); err != nil {
+		return err
+	}
+	return sw.rc.Flush()
+}
+
+// writeData writes data as `data:` lines, one for each of its lines, which the
+// browser joins back with "\n".
+func writeData(sb *strings.Builder, data string) {
+	for _, line := range splitLines(data) {
+		sb.WriteString("data: " + line + "\n")
+	}
+}
+
+// splitLines splits s at "\r\n", "\r" and "\n", which all end a line in an event
+// stream.
+func splitLines(s string) []string {
+	return strings.Split(strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n"), "\n")
+}
@@ -0,0 +1,147 @@
+package sse_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/choonkeat/dom-go"
+	"github.com/choonkeat/dom-go/sse"
+)
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name  string
+		given sse.Event
+		want  string
+	}{
+		{
+			name:  "data",
+			given: sse.Event{Data: dom.Span(dom.Attrs("class", "badge"), dom.InnerText("3"))},
+			want:  "data: <span class=\"badge\">3</span>\n\n",
+		},
+		{
+			name: "name, id and retry",
+			given: sse.Event{
+				Name:  "progress",
+				ID:    "42",
+				Retry: 2500 * time.Millisecond,
+				Data:  dom.InnerText("50%"),
+			},
+			want: "event: progress\nid: 42\nretry: 2500\ndata: 50%\n\n",
+		},
+		{
+			name:  "lines",
+			given: sse.Event{Data: dom.Pre(dom.Attrs(), dom.InnerText("a\nb\r\nc\rd\n"))},
+			want:  "data: <pre>a\ndata: b\ndata: c\ndata: d\ndata: </pre>\n\n",
+		},
+		{
+			name:  "raw",
+			given: sse.Event{Name: "patch", Data: dom.InnerHTML(`{"a":"<b>"}`)},
+			want:  "event: patch\ndata: {\"a\":\"<b>\"}\n\n",
+		},
+		{
+			name:  "empty",
+			given: sse.Event{Name: "clear"},
+			want:  "event: clear\ndata: \n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			stream, err := sse.NewWriter(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := stream.Send(tt.given); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+				t.Errorf("got Content-Type %q", got)
+			}
+			if !w.Flushed {
+				t.Errorf("not flushed")
+			}
+		})
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	stream, err := sse.NewWriter(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stream.Send(sse.Event{Name: "a\ndata: b"}); err == nil {
+		t.Errorf("got no error for a name with a line break")
+	}
+	if err := stream.Send(sse.Event{ID: "1\r2"}); err == nil {
+		t.Errorf("got no error for an id with a line break")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("got %q", w.Body.String())
+	}
+
+	cancel()
+	<-stream.Done()
+	if err := stream.Send(sse.Event{Data: dom.InnerText("x")}); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v but want context.Canceled", err)
+	}
+	if err := stream.Comment("x"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v but want context.Canceled", err)
+	}
+}
+
+func TestComment(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream, err := sse.NewWriter(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Comment("keep\nalive"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Body.String(), ": keep\n: alive\n\n"; got != want {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+}
+
+// writer is a ResponseWriter that cannot be flushed, unless it wraps one that can.
+type writer struct {
+	http.ResponseWriter
+}
+
+type wrapper struct {
+	writer
+}
+
+func (w wrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestNewWriter(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, err := sse.NewWriter(writer{httptest.NewRecorder()}, r); !errors.Is(err, sse.ErrStreamingUnsupported) {
+		t.Errorf("got %v but want ErrStreamingUnsupported", err)
+	}
+
+	w := httptest.NewRecorder()
+	stream, err := sse.NewWriter(wrapper{writer{w}}, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(sse.Event{Data: dom.InnerText("ok")}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Body.String(), "data: ok\n\n"; got != want || !w.Flushed {
+		t.Errorf("\ngot      %q\nbut want %q", got, want)
+	}
+}
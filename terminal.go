@@ -0,0 +1,178 @@
+package dom
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TerminalOptions configures Node.Terminal.
+type TerminalOptions struct {
+	// Width wraps lines at this many characters. Zero or less does not wrap.
+	Width int
+
+	// Color writes styles, colored headings and hyperlinks as ANSI escape codes.
+	// Without it, links are written as `text (url)` and headings are underlined.
+	Color bool
+}
+
+// TerminalOptionsFor returns the options to write to f: lines are wrapped at the
+// `COLUMNS` environment variable, or DefaultTextWidth, and color is used only if
+// f is a terminal and `NO_COLOR` is not set.
+func TerminalOptionsFor(f *os.File) TerminalOptions {
+	opts := TerminalOptions{Width: DefaultTextWidth}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		opts.Width = columns
+	}
+	if os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			opts.Color = true
+		}
+	}
+	return opts
+}
+
+// Terminal returns the node laid out as text for a terminal, e.g. to print a
+// report in a command line tool. It is like Text, but tables are drawn in boxes,
+// and with Color, `<strong>`, `<em>`, `<u>`, `<del>` and `<code>` are styled,
+// headings colored and `<a>` written as OSC 8 hyperlinks. Control characters in
+// the text are left out.
+//
+// Example:
+//
+//	fmt.Println(report.Terminal(dom.TerminalOptionsFor(os.Stdout)))
+func (e Node) Terminal(opts TerminalOptions) string {
+	tl := &textLayout{terminal: true, color: opts.Color}
+	return strings.Join(tl.lines([]Node{e}, opts.Width), "\n")
+}
+
+// inlineStyles are the SGR parameters of inline elements.
+var inlineStyles = map[string]string{
+	"strong": "1", "b": "1",
+	"em": "3", "i": "3", "cite": "3", "var": "3",
+	"u": "4", "ins": "4",
+	"del": "9", "s": "9",
+	"code": "36", "kbd": "36", "samp": "36",
+}
+
+// headingStyles are the SGR parameters of headings.
+var headingStyles = map[string]string{
+	"h1": "1;4;35", "h2": "1;35", "h3": "1;36",
+	"h4": "1;34", "h5": "1;34", "h6": "1;34",
+}
+
+// styledLines is like contentLines, with the text of n in the given style.
+func (tl *textLayout) styledLines(n Node, width int, style string) []string {
+	tl.styles = append(tl.styles, style)
+	defer func() { tl.styles = tl.styles[:len(tl.styles)-1] }()
+	return tl.contentLines(n, width)
+}
+
+// styleText drops the control characters in s, and writes each word in the styles
+// and link around it. Words are styled on their own so that lines can be wrapped
+// and indented between them.
+func (tl *textLayout) styleText(s string) string {
+	s = stripControl(s)
+	if !tl.color || (len(tl.styles) == 0 && tl.link == "") {
+		return s
+	}
+	var before, after string
+	if len(tl.styles) > 0 {
+		before = "\x1b[" + strings.Join(tl.styles, ";") + "m"
+		after = "\x1b[0m"
+	}
+	if tl.link != "" {
+		before += "\x1b]8;;" + stripControl(tl.link) + "\x1b\\"
+		after = "\x1b]8;;\x1b\\" + after
+	}
+
+	var sb strings.Builder
+	word := -1 // start of the current word
+	for i, r := range s {
+		switch {
+		case isHTMLSpace(r) && word >= 0:
+			sb.WriteString(before + s[word:i] + after)
+			word = -1
+			fallthrough
+		case isHTMLSpace(r):
+			sb.WriteRune(r)
+		case word < 0:
+			word = i
+		}
+	}
+	if word >= 0 {
+		sb.WriteString(before + s[word:] + after)
+	}
+	return sb.String()
+}
+
+// stripControl returns s without control characters, besides tabs and newlines.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// escapeEnd returns the length of the ANSI escape sequence that s starts with, or 0.
+func escapeEnd(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' {
+		return 0
+	}
+	switch s[1] {
+	case '[': // CSI, ended by a byte in @ to ~
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']': // OSC, ended by BEL or ST
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	}
+	return 0
+}
+
+// boxTable returns rows drawn in a box, with the cells aligned in columns and
+// header rows ruled off.
+func boxTable(rows []textRow, widths []int) []string {
+	if len(widths) == 0 {
+		return nil
+	}
+	rule := func(left, middle, right string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			parts[i] = strings.Repeat("─", w+2)
+		}
+		return left + strings.Join(parts, middle) + right
+	}
+
+	lines := []string{rule("┌", "┬", "┐")}
+	for i, r := range rows {
+		for j := 0; j < r.height(); j++ {
+			var sb strings.Builder
+			sb.WriteString("│")
+			for k, w := range widths {
+				line := ""
+				if k < len(r.cells) {
+					line = r.cells[k].line(j)
+				}
+				sb.WriteString(" " + line + strings.Repeat(" ", w-textWidth(line)) + " │")
+			}
+			lines = append(lines, sb.String())
+		}
+		if r.header && i+1 < len(rows) && !rows[i+1].header {
+			lines = append(lines, rule("├", "┼", "┤"))
+		}
+	}
+	return append(lines, rule("└", "┴", "┘"))
+}
@@ -0,0 +1,134 @@
+package dom_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+)
+
+func TestTerminal(t *testing.T) {
+	report := dom.Article(dom.Attrs(),
+		dom.H1(dom.Attrs(), dom.InnerText("Report")),
+		dom.P(dom.Attrs(),
+			dom.InnerText("Some "),
+			dom.Strong(dom.Attrs(), dom.InnerText("bold"), dom.Em(dom.Attrs(), dom.InnerText(" text"))),
+			dom.InnerText(" and a "),
+			dom.A(dom.Attrs("href", "http://x.com"), dom.InnerText("link here")),
+			dom.InnerText(" \x1b[31mred?"),
+		),
+		dom.Ul(dom.Attrs(),
+			dom.Li(dom.Attrs(), dom.InnerText("one")),
+			dom.Li(dom.Attrs(), dom.InnerText("two"), dom.Ol(dom.Attrs(), dom.Li(dom.Attrs(), dom.InnerText("three")))),
+		),
+		dom.Table(dom.Attrs(),
+			dom.Thead(dom.Attrs(), dom.Tr(dom.Attrs(), dom.Th(dom.Attrs(), dom.InnerText("Name")), dom.Th(dom.Attrs(), dom.InnerText("Qty")))),
+			dom.Tbody(dom.Attrs(),
+				dom.Tr(dom.Attrs(), dom.Td(dom.Attrs(), dom.InnerText("apple")), dom.Td(dom.Attrs(), dom.InnerText("3"))),
+				dom.Tr(dom.Attrs(), dom.Td(dom.Attrs(), dom.InnerText("kiwi"))),
+			),
+		),
+		dom.Blockquote(dom.Attrs(), dom.P(dom.Attrs(), dom.InnerText("quoted"))),
+		dom.Hr(dom.Attrs()),
+	)
+
+	tests := []struct {
+		name  string
+		given dom.Node
+		opts  dom.TerminalOptions
+		want  string
+	}{
+		{
+			name:  "no color",
+			given: report,
+			opts:  dom.TerminalOptions{Width: 20},
+			want: strings.Join([]string{
+				"Report",
+				"======",
+				"",
+				"Some bold text and a",
+				"link here",
+				"(http://x.com)",
+				"[31mred?",
+				"",
+				"• one",
+				"• two",
+				"  1. three",
+				"",
+				"┌───────┬─────┐",
+				"│ Name  │ Qty │",
+				"├───────┼─────┤",
+				"│ apple │ 3   │",
+				"│ kiwi  │     │",
+				"└───────┴─────┘",
+				"",
+				"│ quoted",
+				"",
+				"────────────────────",
+			}, "\n"),
+		},
+		{
+			name:  "color",
+			given: report,
+			opts:  dom.TerminalOptions{Width: 20, Color: true},
+			want: strings.Join([]string{
+				"\x1b[1;4;35mReport\x1b[0m",
+				"",
+				"Some \x1b[1mbold\x1b[0m \x1b[1;3mtext\x1b[0m and a",
+				"\x1b]8;;http://x.com\x1b\\link\x1b]8;;\x1b\\ \x1b]8;;http://x.com\x1b\\here\x1b]8;;\x1b\\ [31mred?",
+				"",
+				"• one",
+				"• two",
+				"  1. three",
+				"",
+				"┌───────┬─────┐",
+				"│ \x1b[1mName\x1b[0m  │ \x1b[1mQty\x1b[0m │",
+				"├───────┼─────┤",
+				"│ apple │ 3   │",
+				"│ kiwi  │     │",
+				"└───────┴─────┘",
+				"",
+				"│ quoted",
+				"",
+				"────────────────────",
+			}, "\n"),
+		},
+		{
+			name: "styled link",
+			given: dom.P(dom.Attrs(),
+				dom.A(dom.Attrs("href", "/a\x07b"), dom.Code(dom.Attrs(), dom.InnerText("go"))),
+				dom.U(dom.Attrs(), dom.InnerText("under")),
+				dom.Del(dom.Attrs(), dom.InnerText("gone")),
+			),
+			opts: dom.TerminalOptions{Color: true},
+			want: "\x1b[36m\x1b]8;;/ab\x1b\\go\x1b]8;;\x1b\\\x1b[0m\x1b[4munder\x1b[0m\x1b[9mgone\x1b[0m",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.given.Terminal(tt.opts)
+			if got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminalOptionsFor(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Setenv("COLUMNS", "")
+	if got, want := dom.TerminalOptionsFor(f), (dom.TerminalOptions{Width: dom.DefaultTextWidth}); got != want {
+		t.Errorf("\ngot      %#v\nbut want %#v", got, want)
+	}
+	t.Setenv("COLUMNS", "100")
+	if got, want := dom.TerminalOptionsFor(f), (dom.TerminalOptions{Width: 100}); got != want {
+		t.Errorf("\ngot      %#v\nbut want %#v", got, want)
+	}
+}
@@ -0,0 +1,560 @@
+package dom
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/choonkeat/dom-go/internal/htmltoken"
+)
+
+// DefaultTextWidth is the width Node.Text wraps lines at.
+const DefaultTextWidth = 72
+
+// Text returns a readable plain text representation of the node, e.g. for the
+// text/plain alternative of an html email. Lines are wrapped at DefaultTextWidth
+// characters.
+//
+// Block elements are separated by newlines, and paragraphs by blank lines. Lists
+// are marked with `*` or numbers, links are written as `text (url)`, headings are
+// underlined and tables aligned in columns. `<script>` and `<style>` are left out.
+func (e Node) Text() string {
+	return e.TextWidth(DefaultTextWidth)
+}
+
+// TextWidth is like Text, but wraps lines at width characters instead. Zero or
+// less does not wrap. Words longer than width, and tables, are not broken up.
+func (e Node) TextWidth(width int) string {
+	tl := &textLayout{}
+	return strings.Join(tl.lines([]Node{e}, width), "\n")
+}
+
+// textLayout renders nodes into lines of plain text.
+type textLayout struct {
+	listDepth int // lists inside list items are not set apart by blank lines
+
+	terminal bool     // box-drawn tables, `•` bullets, and no control characters
+	color    bool     // ANSI escape codes for styles and hyperlinks
+	styles   []string // SGR parameters of the elements around the text
+	link     string   // href of the `<a>` around the text
+}
+
+// builder returns a textBuilder for a box of the given width.
+func (tl *textLayout) builder(width int, pre bool) *textBuilder {
+	b := &textBuilder{width: width, pre: pre}
+	if tl.terminal {
+		b.style = tl.styleText
+	}
+	return b
+}
+
+// lines returns nodes laid out in lines of at most width characters.
+func (tl *textLayout) lines(nodes []Node, width int) []string {
+	b := tl.builder(width, false)
+	for _, n := range nodes {
+		tl.add(b, n)
+	}
+	return b.finish()
+}
+
+func (tl *textLayout) add(b *textBuilder, n Node) {
+	name := strings.ToLower(n.Name)
+	switch name {
+	case "":
+		tl.addContent(b, n)
+
+	case "script", "style", "template", "head":
+
+	case "br":
+		b.lineBreak()
+
+	case "hr":
+		width := b.width
+		if width <= 0 {
+			width = DefaultTextWidth
+		}
+		rule := "-"
+		if tl.terminal {
+			rule = "─"
+		}
+		b.block([]string{strings.Repeat(rule, width)}, true)
+
+	case "img":
+		if alt, ok := n.Attr("alt"); ok {
+			b.text(alt)
+		}
+
+	case "a":
+		if href, ok := n.Attr("href"); ok && href != "" && tl.color {
+			link := tl.link
+			tl.link = href
+			tl.addContent(b, n)
+			tl.link = link
+			return
+		}
+		text := strings.Join(tl.lines(n.Children, 0), " ")
+		if n.InnerHTML != "" || n.InnerText != "" {
+			text = strings.Join(tl.lines([]Node{{InnerHTML: n.InnerHTML, InnerText: n.InnerText}}, 0), " ")
+		}
+		b.text(text)
+		if href, ok := n.Attr("href"); ok && href != "" && !strings.HasPrefix(href, "#") && href != text && href != "mailto:"+text {
+			b.text(" (" + href + ")")
+		}
+
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if tl.color {
+			b.block(tl.styledLines(n, b.width, headingStyles[name]), true)
+			return
+		}
+		lines := tl.contentLines(n, b.width)
+		underline := "-"
+		if name == "h1" {
+			underline = "="
+		}
+		longest := 0
+		for _, line := range lines {
+			if w := textWidth(line); w > longest {
+				longest = w
+			}
+		}
+		if longest > 0 {
+			lines = append(lines, strings.Repeat(underline, longest))
+		}
+		b.block(lines, true)
+
+	case "p":
+		b.block(tl.contentLines(n, b.width), true)
+
+	case "pre":
+		pre := tl.builder(0, true)
+		tl.addContent(pre, n)
+		b.block(pre.finish(), true)
+
+	case "blockquote":
+		quote := "> "
+		if tl.terminal {
+			quote = "│ "
+		}
+		b.block(indentLines(tl.contentLines(n, innerWidth(b.width, 2)), quote, quote), true)
+
+	case "ul", "ol":
+		b.block(tl.list(n, b.width), tl.listDepth == 0)
+
+	case "dd":
+		b.block(indentLines(tl.contentLines(n, innerWidth(b.width, 4)), "    ", "    "), false)
+
+	case "dl", "figure":
+		b.block(tl.contentLines(n, b.width), true)
+
+	case "table":
+		b.block(tl.table(n), true)
+
+	default:
+		if textBlockElements[name] {
+			b.block(tl.contentLines(n, b.width), false)
+			return
+		}
+		if style, ok := inlineStyles[name]; ok && tl.color {
+			tl.styles = append(tl.styles, style)
+			tl.addContent(b, n)
+			tl.styles = tl.styles[:len(tl.styles)-1]
+			return
+		}
+		tl.addContent(b, n)
+	}
+}
+
+// addContent adds the content of n, i.e. its InnerHTML, InnerText or children.
+func (tl *textLayout) addContent(b *textBuilder, n Node) {
+	switch {
+	case n.InnerHTML != "":
+		b.html(string(n.InnerHTML))
+	case n.InnerText != "":
+		b.text(n.InnerText)
+	default:
+		for _, child := range n.Children {
+			tl.add(b, child)
+		}
+	}
+}
+
+// contentLines returns the content of n laid out in lines of at most width characters.
+func (tl *textLayout) contentLines(n Node, width int) []string {
+	b := tl.builder(width, false)
+	tl.addContent(b, n)
+	return b.finish()
+}
+
+// list returns the items of a `<ul>` or `<ol>` with their bullets or numbers.
+func (tl *textLayout) list(n Node, width int) []string {
+	tl.listDepth++
+	defer func() { tl.listDepth-- }()
+
+	number := 1
+	if start, ok := n.Attr("start"); ok {
+		if i, err := strconv.Atoi(start); err == nil {
+			number = i
+		}
+	}
+
+	var lines []string
+	for _, item := range textChildren(n.Children) {
+		if item.Name == "" && strings.TrimSpace(textOf(item)) == "" {
+			continue
+		}
+		marker := "* "
+		if tl.terminal {
+			marker = "• "
+		}
+		if strings.EqualFold(n.Name, "ol") {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		itemLines := tl.contentLines(item, innerWidth(width, textWidth(marker)))
+		if len(itemLines) == 0 {
+			itemLines = []string{""}
+		}
+		lines = append(lines, indentLines(itemLines, marker, strings.Repeat(" ", textWidth(marker)))...)
+	}
+	return lines
+}
+
+// table returns the caption and rows of a table, with the cells aligned in columns
+// and header rows underlined.
+func (tl *textLayout) table(n Node) []string {
+	caption, rows := tl.tableRows(n)
+	widths := columnWidths(rows)
+	if tl.terminal {
+		return append(caption, boxTable(rows, widths)...)
+	}
+
+	lines := caption
+	for i, r := range rows {
+		for j := 0; j < r.height(); j++ {
+			var sb strings.Builder
+			for k, cell := range r.cells {
+				if k > 0 {
+					sb.WriteString("  ")
+				}
+				line := cell.line(j)
+				sb.WriteString(line)
+				sb.WriteString(strings.Repeat(" ", widths[k]-textWidth(line)))
+			}
+			lines = append(lines, strings.TrimRight(sb.String(), " "))
+		}
+		if r.header && (i+1 == len(rows) || !rows[i+1].header) {
+			underlines := make([]string, len(r.cells))
+			for k := range r.cells {
+				underlines[k] = strings.Repeat("-", widths[k])
+			}
+			lines = append(lines, strings.Join(underlines, "  "))
+		}
+	}
+	return lines
+}
+
+// textRow is a row of a table, with the lines of each cell.
+type textRow struct {
+	cells  []textCell
+	header bool
+}
+
+type textCell []string
+
+// height is the number of lines of the tallest cell.
+func (r textRow) height() int {
+	height := 1
+	for _, cell := range r.cells {
+		if len(cell) > height {
+			height = len(cell)
+		}
+	}
+	return height
+}
+
+// line returns the i-th line of the cell, or "" past its end.
+func (c textCell) line(i int) string {
+	if i < len(c) {
+		return c[i]
+	}
+	return ""
+}
+
+// tableRows returns the caption and rows of a table, with the cells laid out
+// without wrapping.
+func (tl *textLayout) tableRows(n Node) ([]string, []textRow) {
+	var caption []string
+	var rows []textRow
+	var collect func(nodes []Node, header bool)
+	collect = func(nodes []Node, header bool) {
+		for _, child := range textChildren(nodes) {
+			switch strings.ToLower(child.Name) {
+			case "caption":
+				caption = append(caption, tl.contentLines(child, 0)...)
+			case "thead":
+				collect(child.Children, true)
+			case "tbody", "tfoot":
+				collect(child.Children, false)
+			case "tr":
+				r := textRow{header: header}
+				allHeaders := true
+				for _, cell := range textChildren(child.Children) {
+					switch strings.ToLower(cell.Name) {
+					case "th":
+						if tl.color {
+							r.cells = append(r.cells, tl.styledLines(cell, 0, "1"))
+							continue
+						}
+					case "td":
+						allHeaders = false
+					default:
+						continue
+					}
+					r.cells = append(r.cells, tl.contentLines(cell, 0))
+				}
+				r.header = r.header || (allHeaders && len(r.cells) > 0)
+				rows = append(rows, r)
+			}
+		}
+	}
+	collect(n.Children, false)
+	return caption, rows
+}
+
+// columnWidths returns the width of the widest line in each column.
+func columnWidths(rows []textRow) []int {
+	var widths []int
+	for _, r := range rows {
+		for i, cell := range r.cells {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			for _, line := range cell {
+				if w := textWidth(line); w > widths[i] {
+					widths[i] = w
+				}
+			}
+		}
+	}
+	return widths
+}
+
+// textBlockElements are laid out on lines of their own.
+var textBlockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "body": true, "caption": true,
+	"details": true, "dialog": true, "div": true, "dt": true, "fieldset": true,
+	"figcaption": true, "footer": true, "form": true, "header": true, "hgroup": true,
+	"html": true, "li": true, "main": true, "nav": true, "section": true,
+	"summary": true, "tr": true,
+}
+
+// textBuilder collects the lines of a box of text. Inline content is gathered into
+// a paragraph, which is wrapped when a block starts or the box is finished.
+type textBuilder struct {
+	width     int
+	pre       bool     // keep whitespace and do not wrap
+	out       []string // lines of the blocks so far
+	margin    bool     // the last block wants a blank line after it
+	paragraph []string // the lines of inline content so far, split at `<br>`
+
+	style func(string) string // if set, applied to all text
+}
+
+func (b *textBuilder) text(s string) {
+	if b.style != nil {
+		s = b.style(s)
+	}
+	if len(b.paragraph) == 0 {
+		b.paragraph = []string{""}
+	}
+	b.paragraph[len(b.paragraph)-1] += s
+}
+
+func (b *textBuilder) lineBreak() {
+	if b.pre {
+		b.text("\n")
+		return
+	}
+	if len(b.paragraph) == 0 {
+		b.paragraph = []string{""}
+	}
+	b.paragraph = append(b.paragraph, "")
+}
+
+// html adds a fragment of html as text: tags are dropped, but `<br>` and the tags
+// of block elements start new lines.
+func (b *textBuilder) html(s string) {
+	for _, token := range htmltoken.Tokenize(s) {
+		switch name := token.Name; {
+		case token.Kind == htmltoken.Text:
+			b.text(html.UnescapeString(s[token.Start:token.End]))
+		case token.Kind != htmltoken.StartTag && token.Kind != htmltoken.EndTag:
+			// comments, and the contents of `<script>` and `<style>`
+		case name == "br":
+			b.lineBreak()
+		case !b.pre && (textBlockElements[name] || name == "p" || name == "ul" || name == "ol" || name == "table" || name == "blockquote" || name == "pre" || (len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6')):
+			if len(b.paragraph) > 0 && strings.TrimFunc(b.paragraph[len(b.paragraph)-1], isHTMLSpace) != "" {
+				b.lineBreak()
+			}
+		}
+	}
+}
+
+// block adds the lines of a block element, after the paragraph so far.
+func (b *textBuilder) block(lines []string, margin bool) {
+	b.flush()
+	b.appendLines(lines, margin)
+}
+
+func (b *textBuilder) appendLines(lines []string, margin bool) {
+	if len(lines) == 0 {
+		return
+	}
+	if len(b.out) > 0 && (margin || b.margin) {
+		b.out = append(b.out, "")
+	}
+	b.out = append(b.out, lines...)
+	b.margin = margin
+}
+
+// flush wraps the paragraph so far into lines.
+func (b *textBuilder) flush() {
+	if len(b.paragraph) == 0 {
+		return
+	}
+	var lines []string
+	if b.pre {
+		lines = strings.Split(strings.TrimSuffix(strings.TrimPrefix(b.paragraph[0], "\n"), "\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " \t\r")
+		}
+	} else {
+		for _, segment := range b.paragraph {
+			wrapped := wrapText(segment, b.width)
+			if len(wrapped) == 0 {
+				wrapped = []string{""}
+			}
+			lines = append(lines, wrapped...)
+		}
+		for len(lines) > 0 && lines[0] == "" {
+			lines = lines[1:]
+		}
+		for len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+	}
+	b.paragraph = nil
+	b.appendLines(lines, false)
+}
+
+func (b *textBuilder) finish() []string {
+	b.flush()
+	return b.out
+}
+
+// wrapText collapses the html whitespace in s and wraps it into lines of at most
+// width characters. Zero or less width does not wrap.
+func wrapText(s string, width int) []string {
+	words := strings.FieldsFunc(s, isHTMLSpace)
+	if width <= 0 {
+		if len(words) == 0 {
+			return nil
+		}
+		return []string{strings.Join(words, " ")}
+	}
+	var lines []string
+	var line strings.Builder
+	n := 0
+	for _, word := range words {
+		w := textWidth(word)
+		if n > 0 && n+1+w > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			n = 0
+		}
+		if n > 0 {
+			line.WriteByte(' ')
+			n++
+		}
+		line.WriteString(word)
+		n += w
+	}
+	if n > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// indentLines returns lines with first before the first line, and rest before the
+// others. Empty lines stay empty, besides the first.
+func indentLines(lines []string, first, rest string) []string {
+	indented := make([]string, len(lines))
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			indented[i] = strings.TrimRight(first+line, " ")
+		case line == "":
+			indented[i] = strings.TrimRight(rest, " ")
+		default:
+			indented[i] = rest + line
+		}
+	}
+	return indented
+}
+
+// innerWidth is the width left after indenting a box of the given width.
+func innerWidth(width, indent int) int {
+	if width <= 0 {
+		return 0
+	}
+	if width-indent < 1 {
+		return 1
+	}
+	return width - indent
+}
+
+// textChildren returns nodes with the children of fragments in their place.
+func textChildren(nodes []Node) []Node {
+	var children []Node
+	for _, n := range nodes {
+		if n.Name == "" && n.InnerHTML == "" && n.InnerText == "" {
+			children = append(children, textChildren(n.Children)...)
+			continue
+		}
+		children = append(children, n)
+	}
+	return children
+}
+
+// textOf returns the InnerText, or InnerHTML, of a text node.
+func textOf(n Node) string {
+	if n.InnerHTML != "" {
+		return string(n.InnerHTML)
+	}
+	return n.InnerText
+}
+
+// textWidth is the number of characters in s, not counting ANSI escape sequences.
+func textWidth(s string) int {
+	if strings.IndexByte(s, '\x1b') < 0 {
+		return utf8.RuneCountInString(s)
+	}
+	n := 0
+	for len(s) > 0 {
+		if end := escapeEnd(s); end > 0 {
+			s = s[end:]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		n++
+	}
+	return n
+}
+
+// isHTMLSpace reports whether r is html whitespace. Unlike unicode.IsSpace, a
+// non-breaking space is not.
+func isHTMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
@@ -0,0 +1,185 @@
+package dom_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name  string
+		given dom.Node
+		width int
+		want  string
+	}{
+		{
+			name: "blocks and inline elements",
+			given: dom.Div(dom.Attrs(),
+				dom.H1(dom.Attrs(), dom.InnerText("Welcome")),
+				dom.P(dom.Attrs(),
+					dom.InnerText("Hello  "),
+					dom.Strong(dom.Attrs(), dom.InnerText("Alice")),
+					dom.InnerText(",\n  thanks for signing up."),
+				),
+				dom.H2(dom.Attrs(), dom.InnerText("Next steps")),
+				dom.Div(dom.Attrs(), dom.InnerText("One")),
+				dom.Div(dom.Attrs(), dom.InnerText("Two"), dom.Br(dom.Attrs()), dom.InnerText("Three")),
+				dom.Script(dom.Attrs(), dom.InnerText("alert(1)")),
+				dom.Style(dom.Attrs(), dom.InnerText("p { color: red }")),
+				dom.Hr(dom.Attrs()),
+				dom.InnerText("Bye"),
+			),
+			width: 10,
+			want: strings.Join([]string{
+				"Welcome",
+				"=======",
+				"",
+				"Hello",
+				"Alice,",
+				"thanks for",
+				"signing",
+				"up.",
+				"",
+				"Next steps",
+				"----------",
+				"",
+				"One",
+				"Two",
+				"Three",
+				"",
+				"----------",
+				"",
+				"Bye",
+			}, "\n"),
+		},
+		{
+			name: "links and images",
+			given: dom.P(dom.Attrs(),
+				dom.A(dom.Attrs("href", "https://example.com/confirm"), dom.InnerText("Confirm")),
+				dom.InnerText(" or visit "),
+				dom.A(dom.Attrs("href", "https://example.com"), dom.InnerText("https://example.com")),
+				dom.InnerText(", "),
+				dom.A(dom.Attrs("href", "mailto:help@example.com"), dom.InnerText("help@example.com")),
+				dom.InnerText(" and "),
+				dom.A(dom.Attrs("href", "#top"), dom.InnerText("top")),
+				dom.Img(dom.Attrs("src", "logo.png", "alt", " [logo]")),
+				dom.Img(dom.Attrs("src", "pixel.png")),
+			),
+			want: "Confirm (https://example.com/confirm) or visit https://example.com,\nhelp@example.com and top [logo]",
+		},
+		{
+			name: "lists",
+			given: dom.Div(dom.Attrs(),
+				dom.Ul(dom.Attrs(),
+					dom.Li(dom.Attrs(), dom.InnerText("Fruit"),
+						dom.Ol(dom.Attrs("start", "9"),
+							dom.Li(dom.Attrs(), dom.InnerText("Apple")),
+							dom.Li(dom.Attrs(), dom.InnerText("Banana split with extra cream")),
+						),
+					),
+					dom.InnerText("\n"),
+					dom.Li(dom.Attrs(), dom.InnerText("Vegetables")),
+				),
+				dom.P(dom.Attrs(), dom.InnerText("Done")),
+			),
+			width: 20,
+			want: strings.Join([]string{
+				"* Fruit",
+				"  9. Apple",
+				"  10. Banana split",
+				"      with extra",
+				"      cream",
+				"* Vegetables",
+				"",
+				"Done",
+			}, "\n"),
+		},
+		{
+			name: "tables",
+			given: dom.Table(dom.Attrs(),
+				dom.Caption(dom.Attrs(), dom.InnerText("Invoice")),
+				dom.Thead(dom.Attrs(),
+					dom.Tr(dom.Attrs(),
+						dom.Th(dom.Attrs(), dom.InnerText("Item")),
+						dom.Th(dom.Attrs(), dom.InnerText("Price")),
+					),
+				),
+				dom.Tbody(dom.Attrs(),
+					dom.Tr(dom.Attrs(),
+						dom.Td(dom.Attrs(), dom.InnerText("Coffee")),
+						dom.Td(dom.Attrs(), dom.InnerText("3.50")),
+					),
+					dom.Tr(dom.Attrs(),
+						dom.Td(dom.Attrs(), dom.InnerText("Cake"), dom.Br(dom.Attrs()), dom.InnerText("(sliced)")),
+						dom.Td(dom.Attrs(), dom.InnerText("12.00")),
+					),
+				),
+			),
+			want: strings.Join([]string{
+				"Invoice",
+				"Item      Price",
+				"--------  -----",
+				"Coffee    3.50",
+				"Cake      12.00",
+				"(sliced)",
+			}, "\n"),
+		},
+		{
+			name: "preformatted and quoted",
+			given: dom.Div(dom.Attrs(),
+				dom.Pre(dom.Attrs(), dom.InnerText("\nfunc main() {\n\tprintln(\"hi\")\n}\n")),
+				dom.Blockquote(dom.Attrs(),
+					dom.P(dom.Attrs(), dom.InnerText("To be or not to be")),
+					dom.P(dom.Attrs(), dom.InnerText("Hamlet")),
+				),
+			),
+			width: 12,
+			want: strings.Join([]string{
+				"func main() {",
+				"\tprintln(\"hi\")",
+				"}",
+				"",
+				"> To be or",
+				"> not to be",
+				">",
+				"> Hamlet",
+			}, "\n"),
+		},
+		{
+			name: "inner html",
+			given: dom.Div(dom.Attrs(),
+				dom.InnerHTML(`<p>Fish &amp; chips<br/>1 < 2</p><script>var x = "<p>";</script><!-- note --><div title="a>b">Tea</div>`),
+			),
+			want: "Fish & chips\n1 < 2\nTea",
+		},
+		{
+			name: "inner html script with runes that change length when lower cased",
+			given: dom.Div(dom.Attrs(),
+				dom.InnerHTML("<script>"+strings.Repeat("Ⱥ", 20)+"</script>after<SCRIPT>"+strings.Repeat("İ", 10)+"</Script> more"),
+			),
+			want: "after more",
+		},
+		{
+			name: "no wrapping",
+			given: dom.P(dom.Attrs(),
+				dom.InnerText(strings.Repeat("word ", 20)),
+			),
+			width: 0,
+			want:  strings.TrimSpace(strings.Repeat("word ", 20)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.given.Text()
+			if tt.width != 0 || tt.name == "no wrapping" {
+				got = tt.given.TextWidth(tt.width)
+			}
+			if got != tt.want {
+				t.Errorf("\ngot      %q\nbut want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package dom
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracer is told by a Renderer when the rendering of every named node starts and
+// ends, see Component. When Renderer.Tracer is nil, nothing is measured.
+type Tracer interface {
+	Start(event TraceEvent)
+	End(event TraceEvent)
+}
+
+// TraceEvent describes the rendering of a named node.
+type TraceEvent struct {
+	Component string
+
+	// Render identifies the Renderer.HTML call that rendered the node; it is
+	// different for every call, so that a Tracer can tell renders apart.
+	Render uint64
+
+	// Path is the child indices from the rendered root to the node, e.g. `[]int{2, 0}`
+	// is the first child of the third child of the root. The root itself has an empty Path.
+	Path []int
+
+	// Bytes and Duration are only set on End; Bytes is the length of the node's HTML.
+	Bytes    int
+	Duration time.Duration
+}
+
+// TraceCollector is a Tracer that keeps every finished TraceEvent in memory.
+// It is safe for concurrent use.
+//
+// Example:
+//
+//	var c dom.TraceCollector
+//	r := dom.Renderer{Tracer: &c}
+//	r.HTML(page)
+//	fmt.Print(c.Report())
+type TraceCollector struct {
+	mu     sync.Mutex
+	events []TraceEvent
+}
+
+// Start implements Tracer.
+func (c *TraceCollector) Start(event TraceEvent) {}
+
+// End implements Tracer.
+func (c *TraceCollector) End(event TraceEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+// Events returns the collected events, in the order they finished.
+func (c *TraceCollector) Events() []TraceEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]TraceEvent(nil), c.events...)
+}
+
+// Reset discards the collected events, e.g. before collecting another render.
+func (c *TraceCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = nil
+}
+
+// Report returns the collected events in the "folded stacks" format read by
+// flame graph tools, one line per component: the semicolon separated names of
+// the enclosing components, then the microseconds spent in the component itself
+// excluding its nested components, e.g.
+//
+//	page 120
+//	page;sidebar 80
+//	page;sidebar;nav 35
+//
+// Lines are sorted by stack. The times of every collected render are added up.
+func (c *TraceCollector) Report() string {
+	events := c.Events()
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Render != events[j].Render {
+			return events[i].Render < events[j].Render
+		}
+		return comparePaths(events[i].Path, events[j].Path) < 0
+	})
+
+	stacks := map[string]time.Duration{}
+	var keys []string
+	var parents []int // indexes into events of the enclosing components
+	self := make([]time.Duration, len(events))
+	names := make([]string, len(events))
+	for i, event := range events {
+		for len(parents) > 0 && !encloses(events[parents[len(parents)-1]], event) {
+			parents = parents[:len(parents)-1]
+		}
+		names[i] = event.Component
+		self[i] = event.Duration
+		if len(parents) > 0 {
+			parent := parents[len(parents)-1]
+			names[i] = names[parent] + ";" + event.Component
+			self[parent] -= event.Duration
+		}
+		parents = append(parents, i)
+	}
+	for i, name := range names {
+		if _, ok := stacks[name]; !ok {
+			keys = append(keys, name)
+		}
+		if self[i] > 0 {
+			// with Renderer.Workers, nested components overlap and may add up to more than their parent
+			stacks[name] += self[i]
+		}
+	}
+
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&sb, "%s %d\n", key, stacks[key].Microseconds())
+	}
+	return sb.String()
+}
+
+// comparePaths orders paths depth first, i.e. a node before its descendants
+// and its descendants before its next sibling.
+func comparePaths(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return len(a) - len(b)
+}
+
+// encloses reports whether the node of parent encloses the node of event.
+func encloses(parent, event TraceEvent) bool {
+	return parent.Render == event.Render && isPathPrefix(parent.Path, event.Path)
+}
+
+// isPathPrefix reports whether the node at prefix encloses the node at path.
+func isPathPrefix(prefix, path []int) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
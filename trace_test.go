@@ -0,0 +1,101 @@
+package dom_test
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/choonkeat/dom-go"
+)
+
+func TestTraceCollector(t *testing.T) {
+	t.Parallel()
+
+	nav := dom.Component("nav", dom.Ul(dom.Attrs(), dom.Li(dom.Attrs(), dom.InnerText("Home"))))
+	sidebar := dom.Component("sidebar", dom.Aside(dom.Attrs(), dom.H2(dom.Attrs(), dom.InnerText("Menu")), nav))
+	content := dom.Component("content", dom.Element("", nil, dom.P(dom.Attrs(), dom.InnerText("<hello>"))))
+	page := dom.Component("page", dom.Div(dom.Attrs("class", "page"), sidebar, content))
+
+	for _, renderer := range []dom.Renderer{
+		{},
+		{Workers: 4, Threshold: 1},
+	} {
+		var collector dom.TraceCollector
+		renderer.Tracer = &collector
+		if got, want := renderer.HTML(page), page.HTML(); got != want {
+			t.Errorf("got %q but want %q", got, want)
+		}
+
+		events := collector.Events()
+		sort.Slice(events, func(i, j int) bool { return events[i].Component < events[j].Component })
+		type summary struct {
+			Component string
+			Path      []int
+			Bytes     int
+		}
+		var got []summary
+		for _, event := range events {
+			got = append(got, summary{event.Component, event.Path, event.Bytes})
+		}
+		want := []summary{
+			{"content", []int{1}, len(content.HTML())},
+			{"nav", []int{0, 1}, len(nav.HTML())},
+			{"page", []int{}, len(page.HTML())},
+			{"sidebar", []int{0}, len(sidebar.HTML())},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("\ngot      %#v\nbut want %#v", got, want)
+		}
+
+		var stacks []string
+		for _, line := range strings.Split(strings.TrimSpace(collector.Report()), "\n") {
+			stacks = append(stacks, strings.Fields(line)[0])
+		}
+		if want := []string{"page", "page;content", "page;sidebar", "page;sidebar;nav"}; !reflect.DeepEqual(stacks, want) {
+			t.Errorf("\ngot      %#v\nbut want %#v", stacks, want)
+		}
+
+		collector.Reset()
+		if got := collector.Events(); len(got) != 0 {
+			t.Errorf("Reset kept %d events", len(got))
+		}
+	}
+}
+
+func TestTraceCollectorRenders(t *testing.T) {
+	t.Parallel()
+
+	nav := dom.Component("nav", dom.Ul(dom.Attrs(), dom.Li(dom.Attrs(), dom.InnerText("Home"))))
+	page := dom.Component("page", dom.Div(dom.Attrs(), nav))
+
+	for _, renderer := range []dom.Renderer{
+		{},
+		{Workers: 4, Threshold: 1},
+	} {
+		var collector dom.TraceCollector
+		renderer.Tracer = &collector
+		renderer.HTML(page)
+		renderer.HTML(page)
+
+		events := collector.Events()
+		if len(events) != 4 {
+			t.Fatalf("got %d events but want 4", len(events))
+		}
+		renders := map[uint64]int{}
+		for _, event := range events {
+			renders[event.Render]++
+		}
+		if len(renders) != 2 {
+			t.Errorf("got renders %v but want 2 renders of 2 events", renders)
+		}
+
+		var stacks []string
+		for _, line := range strings.Split(strings.TrimSpace(collector.Report()), "\n") {
+			stacks = append(stacks, strings.Fields(line)[0])
+		}
+		if want := []string{"page", "page;nav"}; !reflect.DeepEqual(stacks, want) {
+			t.Errorf("\ngot      %#v\nbut want %#v", stacks, want)
+		}
+	}
+}